@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/ryuki8643/split"
+)
+
+// stdinFileName is the input file name that reads from stdin.
+const stdinFileName = "-"
+
+// ParseFlags parses the command line arguments, without the program name,
+// into the input file name and the split options.
+func ParseFlags(args []string) (string, split.Options, error) {
+	var (
+		options split.Options
+		flagSet int
+	)
+
+	flags := flag.NewFlagSet("split", flag.ContinueOnError)
+	flags.Int64Var(&options.Lines, "l", 0, "Line number for split file")
+	flags.StringVar(&options.Chunks, "n", "", "CHUNKS for split file")
+	flags.BoolVar(&options.LineBalanced, "line-balanced", false, "Make the l/N chunks of the same number of lines instead of the same size")
+	flags.StringVar(&options.Bytes, "b", "", "Byte for split file")
+	flags.StringVar(&options.LineBytes, "C", "", "Put at most SIZE bytes of whole lines per split file")
+	flags.StringVar(&options.LineBytes, "line-bytes", "", "Same as -C")
+	flags.StringVar(&options.Separator, "t", "", "Use SEP instead of newline as the line separator, with the escapes \\0, \\t and \\n")
+	flags.IntVar(&options.Parallel, "parallel", 0, "Copy up to N chunks of -b and -n N at once")
+	flags.StringVar(&options.MaxMemory, "max-memory", "", "Keep the buffers within SIZE bytes, such as 64M")
+	flags.BoolVar(&options.NumericSuffix, "d", false, "Use numeric file name")
+	flags.IntVar(&options.SuffixLength, "a", 0, "Use numeric file name")
+	addSuffixFlags(flags, &options)
+	flags.BoolVar(&options.Decompress, "decompress", false, "Split the decompressed content of gzip, bzip2 and zlib input")
+	flags.StringVar(&options.Compress, "compress", "", "Compress each chunk with CODEC (gzip, zlib) and append its extension")
+	flags.BoolVar(&options.LimitAfterCompression, "limit-after-compression", false, "Apply the -b or -n size to the compressed chunks")
+	flags.StringVar(&options.Manifest, "manifest", "", "Write a JSON manifest describing every chunk to FILE")
+	flags.StringVar(&options.Filter, "filter", "", "Write each chunk to the stdin of COMMAND with $FILE set instead of a file")
+	flags.StringVar(&options.NameTemplate, "name-template", "", "Name the files by TEMPLATE, such as {name}.part{index:04}-of-{total:04}{ext}, instead of the prefix")
+	flags.BoolVar(&options.ContentNames, "content-names", false, "Name the files by the SHA-256 of their content after the prefix, and list them without --manifest")
+	flags.BoolVar(&options.ContentNamesIndex, "content-names-index", false, "Same as --content-names, with the index before the SHA-256")
+	if err := flags.Parse(args); err != nil {
+		return "", split.Options{}, err
+	}
+
+	if options.Lines != 0 {
+		flagSet++
+	}
+	if options.Chunks != "" {
+		flagSet++
+	}
+	if options.Bytes != "" {
+		flagSet++
+	}
+	if options.LineBytes != "" {
+		flagSet++
+	}
+
+	if flagSet > 1 {
+		return "", split.Options{}, fmt.Errorf(tooManyFlagErrorMsg)
+	}
+	var fileName string
+	if flags.NArg() == 0 {
+		fileName = stdinFileName
+	} else if flags.NArg() == 1 {
+		fileName = flags.Args()[0]
+	} else if flags.NArg() == 2 {
+		fileName = flags.Args()[0]
+		options.Prefix = flags.Args()[1]
+	} else {
+		return "", split.Options{}, fmt.Errorf(invalidArgumentErrorMsg, flags.NArg())
+	}
+
+	options.InputName = fileName
+	return fileName, options, nil
+
+}
+
+// addSuffixFlags adds the hexadecimal suffix flag, the suffix flags with an
+// optional FROM and the flags of the rest of the names to flags, next to -d
+// and -a.
+func addSuffixFlags(flags *flag.FlagSet, options *split.Options) {
+	flags.BoolVar(&options.HexSuffix, "x", false, "Use hexadecimal file name")
+	flags.Var(suffixStartFlag{&options.NumericSuffix, &options.SuffixStart}, "numeric-suffixes", "Same as -d, with =FROM the suffix of the first file")
+	flags.Var(suffixStartFlag{&options.HexSuffix, &options.SuffixStart}, "hex-suffixes", "Same as -x, with =FROM the suffix of the first file")
+	flags.StringVar(&options.AdditionalSuffix, "additional-suffix", "", "Append SUFFIX, such as .csv, to the file names")
+	flags.StringVar(&options.OutputDir, "output-dir", "", "Name the files in DIR, which split creates if missing")
+}
+
+// suffixStartFlag is a suffix flag whose value is optional like a bool
+// flag's. A number FROM both selects the suffix and starts the names at FROM.
+type suffixStartFlag struct {
+	selected *bool
+	start    *int
+}
+
+func (f suffixStartFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (f suffixStartFlag) String() string {
+	if f.start == nil {
+		return ""
+	}
+	return strconv.Itoa(*f.start)
+}
+
+func (f suffixStartFlag) Set(value string) error {
+	if start, err := strconv.ParseUint(value, 10, 31); err == nil {
+		*f.selected, *f.start = true, int(start)
+		return nil
+	}
+	selected, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf(suffixStartInvalidErrorMsg, value)
+	}
+	*f.selected = selected
+	return nil
+}
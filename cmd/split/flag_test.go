@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	// set up test cases
+	testCases := []struct {
+		args []string
+		err  error
+	}{
+		{
+			args: []string{"input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"input.txt", "output_"},
+			err:  nil,
+		},
+		{
+			args: []string{"-l", "100", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-n", "10", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-b", "100K", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-d", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-t", `\0`, "-n", "r/3", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-a", "3", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-n", "l/3", "--line-balanced", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-C", "1K", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-b", "1M", "--parallel", "8", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-n", "r/100", "--max-memory", "64M", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"--line-bytes", "1K", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-l", "100", "-n", "10", "input.txt"},
+			err:  fmt.Errorf(tooManyFlagErrorMsg),
+		},
+		{
+			args: []string{"-C", "1K", "-b", "100K", "input.txt"},
+			err:  fmt.Errorf(tooManyFlagErrorMsg),
+		},
+		{
+			args: []string{"-l", "100", "-b", "100K", "input.txt"},
+			err:  fmt.Errorf(tooManyFlagErrorMsg),
+		},
+		{
+			args: []string{"-n", "10", "-b", "100K", "input.txt"},
+			err:  fmt.Errorf(tooManyFlagErrorMsg),
+		},
+		{
+			args: []string{"-l", "100", "-n", "10", "-b", "100K", "input.txt"},
+			err:  fmt.Errorf(tooManyFlagErrorMsg),
+		},
+		{
+			args: []string{"-l", "100", "-d", "-a", "3", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-n", "10", "-d", "-a", "3", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-b", "100K", "-d", "-a", "3", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-l", "100", "-n", "10", "-d", "-a", "3", "input.txt"},
+			err:  fmt.Errorf(tooManyFlagErrorMsg),
+		},
+		{
+			args: []string{"-l", "100", "-b", "100K", "-d", "-a", "3", "input.txt"},
+			err:  fmt.Errorf(tooManyFlagErrorMsg),
+		},
+		{
+			args: []string{"-n", "10", "-b", "100K", "-d", "-a", "3", "input.txt"},
+			err:  fmt.Errorf(tooManyFlagErrorMsg),
+		},
+		{
+			args: []string{"-l", "100", "-n", "10", "-b", "100K", "-d", "-a", "3", "input.txt"},
+			err:  fmt.Errorf(tooManyFlagErrorMsg),
+		},
+		{
+			args: []string{},
+			err:  nil,
+		},
+		{
+			args: []string{"-n", "r/3", "--manifest=manifest.json", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"--decompress=false", "input.txt.gz"},
+			err:  nil,
+		},
+		{
+			args: []string{"-l", "100", "--filter=gzip > $FILE.gz", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-n", "3", "--name-template={name}.part{index:04}-of-{total:04}{ext}", "input.txt"},
+			err:  nil,
+		},
+		{
+			args: []string{"-l", "100", "--additional-suffix=.csv", "--output-dir=parts", "input.csv", "orders-"},
+			err:  nil,
+		},
+		{
+			args: []string{"-b", "1M", "--content-names-index", "--manifest=chunks.json", "input.bin"},
+			err:  nil,
+		},
+		{
+			args: []string{"-"},
+			err:  nil,
+		},
+		{
+			args: []string{"-l", "100", "-", "output_"},
+			err:  nil,
+		},
+		{
+			args: []string{"input.txt", "prefix", "l", "100"},
+			err:  fmt.Errorf(invalidArgumentErrorMsg, 4),
+		},
+	}
+
+	// run test cases
+	for _, tc := range testCases {
+		// call function
+		_, _, err := ParseFlags(tc.args)
+		// check error
+		if err != nil && err.Error() != tc.err.Error() {
+			t.Errorf("Expected error %v but got %v for args %v", tc.err, err, tc.args)
+		}
+	}
+}
+
+func TestParseSuffixFlags(t *testing.T) {
+	testCases := []struct {
+		args    []string
+		numeric bool
+		hex     bool
+		start   int
+		err     bool
+	}{
+		{[]string{"input.txt"}, false, false, 0, false},
+		{[]string{"--numeric-suffixes", "input.txt"}, true, false, 0, false},
+		{[]string{"--numeric-suffixes=1", "input.txt"}, true, false, 1, false},
+		{[]string{"--numeric-suffixes=120", "input.txt"}, true, false, 120, false},
+		{[]string{"-x", "input.txt"}, false, true, 0, false},
+		{[]string{"--hex-suffixes=255", "input.txt"}, false, true, 255, false},
+		{[]string{"--hex-suffixes=false", "input.txt"}, false, false, 0, false},
+		{[]string{"--numeric-suffixes=abc", "input.txt"}, false, false, 0, true},
+		{[]string{"--numeric-suffixes=-1", "input.txt"}, false, false, 0, true},
+	}
+
+	for _, tc := range testCases {
+		_, options, err := ParseFlags(tc.args)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Expected an error for args %v", tc.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected no error but got %v for args %v", err, tc.args)
+			continue
+		}
+		if options.NumericSuffix != tc.numeric || options.HexSuffix != tc.hex || options.SuffixStart != tc.start {
+			t.Errorf("Expected %t, %t, %d but got %t, %t, %d for args %v", tc.numeric, tc.hex, tc.start, options.NumericSuffix, options.HexSuffix, options.SuffixStart, tc.args)
+		}
+	}
+}
+
+func TestParseFlagsKeepsCompressedInput(t *testing.T) {
+	_, options, err := ParseFlags([]string{"-b", "100K", "data.gz", "part"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.Decompress {
+		t.Error("Expected --decompress to be off by default, so that byte chunks join back into the compressed file")
+	}
+	if _, options, _ := ParseFlags([]string{"--decompress", "-l", "100", "data.gz"}); !options.Decompress {
+		t.Error("Expected --decompress to turn decompression on")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ryuki8643/split"
+)
+
+// joinCommand is the first argument that reassembles a split set instead of splitting.
+const joinCommand = "join"
+
+// ParseJoinFlags parses the arguments of the join subcommand into the output
+// file name, where "-" means stdout, and the options naming the chunks.
+func ParseJoinFlags(args []string) (string, split.Options, error) {
+	var (
+		options    split.Options
+		outputName string
+	)
+
+	flags := flag.NewFlagSet("split join", flag.ContinueOnError)
+	flags.StringVar(&options.Chunks, "n", "", "CHUNKS the set was split with, r/N re-interleaves lines")
+	flags.StringVar(&options.Separator, "t", "", "Line separator SEP the r/N set was split with")
+	flags.BoolVar(&options.NumericSuffix, "d", false, "Use numeric file name")
+	flags.IntVar(&options.SuffixLength, "a", 0, "Use numeric file name")
+	addSuffixFlags(flags, &options)
+	flags.StringVar(&outputName, "o", stdinFileName, "Output file, - for stdout")
+	if err := flags.Parse(args); err != nil {
+		return "", split.Options{}, err
+	}
+
+	if flags.NArg() == 1 {
+		options.Prefix = flags.Args()[0]
+	} else if flags.NArg() > 1 {
+		return "", split.Options{}, fmt.Errorf(invalidArgumentErrorMsg, flags.NArg())
+	}
+
+	return outputName, options, nil
+}
+
+func runJoin(args []string) error {
+	outputName, options, err := ParseJoinFlags(args)
+	if err != nil {
+		return err
+	}
+	joiner, err := split.NewJoiner(split.NewFileSource(split.NewFileNameCreater(options)), options.Chunks, options.Separator)
+	if err != nil {
+		return err
+	}
+
+	var output io.WriteCloser = os.Stdout
+	if outputName != stdinFileName {
+		output, err = os.Create(outputName)
+		if err != nil {
+			return fmt.Errorf(fileCreateErrorMsg, err)
+		}
+	}
+
+	joined, err := joiner.Join(output)
+	if output != os.Stdout {
+		if closeErr := output.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if joined == 0 {
+		return fmt.Errorf(noChunkErrorMsg)
+	}
+	return nil
+}
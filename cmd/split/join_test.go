@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestMainJoinRoundRobin(t *testing.T) {
+
+	defer deleteOutputFiles()
+	defer os.Remove("joined.txt")
+
+	// Create a test file with 2003 lines.
+	testFile, err := os.Create("testfile.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		testFile.Close()
+		os.Remove(testFile.Name())
+	}()
+	for i := 1; i <= 2003; i++ {
+		fmt.Fprintln(testFile, "line", i)
+	}
+
+	os.Args = []string{"-test.v", "-n", "r/3", "-d", "testfile.txt", "output"}
+	main()
+
+	os.Args = []string{"-test.v", "join", "-n", "r/3", "-d", "-o", "joined.txt", "output"}
+	main()
+
+	testFileContent, err := os.ReadFile(testFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined, err := os.ReadFile("joined.txt")
+	if err != nil {
+		t.Fatal("joined.txt was not created.")
+	}
+	if string(joined) != string(testFileContent) {
+		t.Fatal("Incorrect joined content.")
+	}
+}
+
+func TestParseJoinFlags(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		outputName string
+		prefix     string
+		err        error
+	}{
+		{[]string{}, "-", "", nil},
+		{[]string{"output"}, "-", "output", nil},
+		{[]string{"-o", "joined.txt", "-n", "r/3", "-d", "-a", "3", "output"}, "joined.txt", "output", nil},
+		{[]string{"output", "extra"}, "", "", fmt.Errorf(invalidArgumentErrorMsg, 2)},
+	}
+
+	for _, tc := range testCases {
+		outputName, options, err := ParseJoinFlags(tc.args)
+		if tc.err != nil {
+			if err == nil || err.Error() != tc.err.Error() {
+				t.Errorf("Expected error %v but got %v for args %v", tc.err, err, tc.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected no error but got %v for args %v", err, tc.args)
+		}
+		if outputName != tc.outputName || options.Prefix != tc.prefix {
+			t.Errorf("Expected %s, %s but got %s, %s for args %v", tc.outputName, tc.prefix, outputName, options.Prefix, tc.args)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ryuki8643/split"
+)
+
+const (
+	fileOpenErrorMsg           = "failed to open the input file:%w"
+	fileCreateErrorMsg         = "failed to create the output file:%w"
+	noChunkErrorMsg            = "no chunk to join"
+	verifyNothingErrorMsg      = "verify needs --manifest or --original"
+	invalidArgumentErrorMsg    = "invalid argument:%d"
+	tooManyFlagErrorMsg        = "only one of -l, -n, -b, -C can be used"
+	suffixStartInvalidErrorMsg = "invalid suffix start:%s"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == joinCommand {
+		if err := runJoin(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == verifyCommand {
+		exitCode, err := runVerify(os.Args[2:], os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return
+	}
+
+	fileName, options, err := ParseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	splitter, err := split.NewSplitter(options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	sink, err := split.NewChunkSink(options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	file, err := openInput(fileName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer file.Close()
+	err = splitter.Split(file, sink)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// openInput opens the input file. "-" means stdin.
+func openInput(fileName string) (*os.File, error) {
+	if fileName == stdinFileName {
+		return os.Stdin, nil
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf(fileOpenErrorMsg, err)
+	}
+	return file, nil
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,11 +10,6 @@ import (
 func TestMain(t *testing.T) {
 
 	defer deleteOutputPrefixXFiles()
-	testing.Init()
-
-	// reset flag set
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-
 	args := []string{"testfile.txt"}
 
 	args = append([]string{"-test.v"}, args...)
@@ -98,11 +92,6 @@ func TestMain(t *testing.T) {
 func TestMainLineFileSplitterSplit1000Lines(t *testing.T) {
 
 	defer deleteOutputFiles()
-	testing.Init()
-
-	// reset flag set
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-
 	args := []string{"-l", "1000", "testfile.txt", "output"}
 
 	args = append([]string{"-test.v"}, args...)
@@ -185,11 +174,6 @@ func TestMainLineFileSplitterSplit1000Lines(t *testing.T) {
 func TestMainByteFileSplitterSplit2500Byte(t *testing.T) {
 
 	defer deleteOutputFiles()
-	testing.Init()
-
-	// reset flag set
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-
 	args := []string{"-b", "1k", "testfile.txt", "output"}
 
 	args = append([]string{"-test.v"}, args...)
@@ -288,11 +272,6 @@ func TestMainByteFileSplitterSplit2500Byte(t *testing.T) {
 
 func TestMainPieceSplitterSelectPieceByteSplitter(t *testing.T) {
 	defer deleteOutputFiles()
-	testing.Init()
-
-	// reset flag set
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-
 	args := []string{"-n", "3", "testfile.txt", "output"}
 
 	args = append([]string{"-test.v"}, args...)
@@ -391,9 +370,6 @@ func TestMainPieceSplitterSelectPieceByteSplitter(t *testing.T) {
 func TestMainPieceSplitterSelectPieceLineSplitter(t *testing.T) {
 
 	defer deleteOutputFiles()
-	// reset flag set
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-
 	args := []string{"-n", "l/3", "testfile.txt", "output"}
 
 	args = append([]string{"-test.v"}, args...)
@@ -438,23 +414,23 @@ func TestMainPieceSplitterSelectPieceLineSplitter(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if countLinesByByte(output1) != 1003 {
-		t.Fatal("outputaa file has incorrect number of lines.Expected 1000, got ", countLinesByByte(output1))
+	if countLinesByByte(output1) != 1077 {
+		t.Fatal("outputaa file has incorrect number of lines.Expected 1077, got ", countLinesByByte(output1))
 	}
 
 	output2, err := os.ReadFile("outputab")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output2) != 1003 {
-		t.Fatal("outputab file has incorrect number of lines. Expected 1000, got ", countLinesByByte(output2))
+	if countLinesByByte(output2) != 965 {
+		t.Fatal("outputab file has incorrect number of lines. Expected 965, got ", countLinesByByte(output2))
 	}
 	output3, err := os.ReadFile("outputac")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output3) != 1001 {
-		t.Fatal("outputac file has incorrect number of lines. Expected 7, got ", countLinesByByte(output3))
+	if countLinesByByte(output3) != 965 {
+		t.Fatal("outputac file has incorrect number of lines. Expected 965, got ", countLinesByByte(output3))
 	}
 
 	if countFiles() != 3 {
@@ -476,9 +452,6 @@ func TestMainPieceSplitterSelectPieceLineSplitter(t *testing.T) {
 func TestMainPieceSplitterSelectPieceRoundRobinLineSplitter(t *testing.T) {
 
 	defer deleteOutputFiles()
-	// reset flag set
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-
 	args := []string{"-n", "r/3", "testfile.txt", "output"}
 
 	args = append([]string{"-test.v"}, args...)
@@ -561,11 +534,6 @@ func TestMainPieceSplitterSelectPieceRoundRobinLineSplitter(t *testing.T) {
 func TestMainNoPrefixAndNumericFileName(t *testing.T) {
 
 	defer deleteOutputPrefixXFiles()
-	testing.Init()
-
-	// reset flag set
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-
 	args := []string{"-l", "700", "-d", "-a", "3", "testfile.txt"}
 
 	args = append([]string{"-test.v"}, args...)
@@ -692,3 +660,114 @@ func deleteOutputPrefixXFiles() {
 		}
 	}
 }
+
+func TestMainReadFromStdin(t *testing.T) {
+
+	defer deleteOutputFiles()
+	args := []string{"-n", "l/3", "-", "output"}
+
+	args = append([]string{"-test.v"}, args...)
+	// set test args
+	os.Args = args
+
+	var data []byte
+	for i := 1; i <= 3007; i++ {
+		data = append(data, fmt.Sprintf("line %d\n", i)...)
+	}
+
+	// Feed the lines through stdin.
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+	os.Stdin = pipeInput(t, data)
+
+	main()
+
+	var output []byte
+	for _, name := range []string{"outputaa", "outputab", "outputac"} {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(name, " was not created.")
+		}
+		output = append(output, content...)
+	}
+
+	if countFiles() != 3 {
+		t.Fatal("Incorrect number of output files.")
+	}
+	if string(output) != string(data) {
+		t.Fatal("Incorrect output file content.")
+	}
+}
+
+func countLinesByByte(data []byte) int {
+	count := 0
+	for _, b := range data {
+		if b == '\n' {
+			count++
+		}
+	}
+	return count
+}
+
+func countFiles() int {
+	outputPrefix := "output" // Prefix of files to be counted
+
+	// Get the current working directory
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println("Failed to get the current working directory:", err)
+		return 0
+	}
+
+	// Get the list of files
+	files, err := filepath.Glob(filepath.Join(currentDir, outputPrefix+"*"))
+	if err != nil {
+		fmt.Println("Failed to get the list of files:", err)
+		return 0
+	}
+
+	return len(files)
+}
+
+func deleteOutputFiles() {
+	outputPrefix := "output" // Prefix of files to be deleted
+
+	// Get the current working directory
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Println("Failed to get the current working directory:", err)
+		return
+	}
+
+	// Get the list of files
+	files, err := filepath.Glob(filepath.Join(currentDir, outputPrefix+"*"))
+	if err != nil {
+		fmt.Println("Failed to get the list of files:", err)
+		return
+	}
+
+	// Delete the files
+	for _, file := range files {
+		err := os.Remove(file)
+		if err != nil {
+			fmt.Println("Failed to delete the file:", err)
+		} else {
+			fmt.Println("File deleted:", file)
+		}
+	}
+}
+
+// pipeInput returns the read end of a pipe that yields data, like stdin fed by `cat`.
+func pipeInput(t *testing.T, data []byte) *os.File {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		writer.Write(data)
+		writer.Close()
+	}()
+	t.Cleanup(func() { reader.Close() })
+	return reader
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ryuki8643/split"
+)
+
+// verifyCommand is the first argument that checks a split set instead of splitting.
+const verifyCommand = "verify"
+
+// verifyErrorExitCode is the exit status when verify cannot run at all. The
+// problems found are reported as the bits of verifyExitCode instead.
+const verifyErrorExitCode = 1
+
+// verifyExitCode returns the exit status bit of a kind of problem:
+// 2 missing, 4 extra, 8 resized and 16 corrupted chunks.
+func verifyExitCode(kind split.VerifyProblemKind) int {
+	return 1 << int(kind)
+}
+
+type verifyFlags struct {
+	options      split.Options
+	manifestName string
+	originalName string
+}
+
+// ParseVerifyFlags parses the arguments of the verify subcommand.
+func ParseVerifyFlags(args []string) (verifyFlags, error) {
+	var parsed verifyFlags
+
+	flags := flag.NewFlagSet("split verify", flag.ContinueOnError)
+	flags.StringVar(&parsed.manifestName, "manifest", "", "Check every chunk against the manifest FILE")
+	flags.StringVar(&parsed.originalName, "original", "", "Compare the joined chunks with the original FILE, - for stdin")
+	flags.StringVar(&parsed.options.Chunks, "n", "", "CHUNKS the set was split with, r/N re-interleaves lines")
+	flags.StringVar(&parsed.options.Separator, "t", "", "Line separator SEP the r/N set was split with")
+	flags.BoolVar(&parsed.options.NumericSuffix, "d", false, "Use numeric file name")
+	flags.IntVar(&parsed.options.SuffixLength, "a", 0, "Use numeric file name")
+	addSuffixFlags(flags, &parsed.options)
+	flags.BoolVar(&parsed.options.Decompress, "decompress", false, "Compare with the decompressed content of a gzip, bzip2 or zlib original, taken from --manifest when given")
+	if err := flags.Parse(args); err != nil {
+		return verifyFlags{}, err
+	}
+
+	if flags.NArg() == 1 {
+		parsed.options.Prefix = flags.Args()[0]
+	} else if flags.NArg() > 1 {
+		return verifyFlags{}, fmt.Errorf(invalidArgumentErrorMsg, flags.NArg())
+	}
+	if parsed.manifestName == "" && parsed.originalName == "" {
+		return verifyFlags{}, fmt.Errorf(verifyNothingErrorMsg)
+	}
+	return parsed, nil
+}
+
+// runVerify prints the problems of the split set and returns the exit status.
+func runVerify(args []string, stdout io.Writer) (int, error) {
+	parsed, err := ParseVerifyFlags(args)
+	if err != nil {
+		return verifyErrorExitCode, err
+	}
+
+	var problems []split.VerifyProblem
+	var source split.ChunkSource = split.NewFileSource(split.NewFileNameCreater(parsed.options))
+	chunkStr, separatorStr := parsed.options.Chunks, parsed.options.Separator
+
+	decompress := parsed.options.Decompress
+
+	if parsed.manifestName != "" {
+		manifest, err := split.ReadManifest(parsed.manifestName)
+		if err != nil {
+			return verifyErrorExitCode, err
+		}
+		source = manifest.Source()
+		found, err := split.VerifyManifest(manifest, source)
+		problems = append(problems, found...)
+		if err != nil {
+			return printProblems(stdout, problems) | verifyErrorExitCode, err
+		}
+
+		if manifest.Suffix.Extension != "" {
+			source = split.NewDecompressSource(source)
+		}
+		chunkStr, separatorStr = "", manifest.Mode.Separator
+		if manifest.Mode.Chunks != nil && manifest.Mode.Chunks.RoundRobin {
+			chunkStr = fmt.Sprintf("r/%d", manifest.Mode.Chunks.N)
+		}
+		// The original is compared the way it was split.
+		decompress = manifest.Input.Decompressed != ""
+	}
+
+	if parsed.originalName != "" {
+		found, err := verifyOriginal(parsed.originalName, source, chunkStr, separatorStr, decompress)
+		problems = append(problems, found...)
+		if err != nil {
+			// The problems found in the manifest are still reported.
+			return printProblems(stdout, problems) | verifyErrorExitCode, err
+		}
+	}
+	return printProblems(stdout, problems), nil
+}
+
+// verifyOriginal compares the chunks of source joined with the original file.
+func verifyOriginal(originalName string, source split.ChunkSource, chunkStr string, separatorStr string, decompress bool) ([]split.VerifyProblem, error) {
+	joiner, err := split.NewJoiner(source, chunkStr, separatorStr)
+	if err != nil {
+		return nil, err
+	}
+	file, err := openInput(originalName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var original io.Reader = file
+	if decompress {
+		if original, err = split.NewDecompressReader(file); err != nil {
+			return nil, err
+		}
+	}
+	return split.VerifyOriginal(original, joiner)
+}
+
+// printProblems prints every problem and returns the exit status they make.
+func printProblems(stdout io.Writer, problems []split.VerifyProblem) int {
+	exitCode := 0
+	for _, problem := range problems {
+		fmt.Fprintln(stdout, problem)
+		exitCode |= verifyExitCode(problem.Kind)
+	}
+	return exitCode
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ryuki8643/split"
+)
+
+func TestRunVerify(t *testing.T) {
+
+	defer deleteOutputFiles()
+
+	// Create a test file with 2003 lines.
+	testFile, err := os.Create("testfile.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		testFile.Close()
+		os.Remove(testFile.Name())
+	}()
+	for i := 1; i <= 2003; i++ {
+		fmt.Fprintln(testFile, "line", i)
+	}
+
+	os.Args = []string{"-test.v", "-l", "500", "--manifest", "output.json", "testfile.txt", "output"}
+	main()
+
+	var stdout bytes.Buffer
+	exitCode, err := runVerify([]string{"--manifest", "output.json", "--original", "testfile.txt"}, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != 0 || stdout.Len() != 0 {
+		t.Fatal("Expected no problem, got ", exitCode, stdout.String())
+	}
+
+	// Lose the second chunk and change the third one.
+	if err := os.Remove("outputab"); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile("outputac")
+	content[0] = 'L'
+	os.WriteFile("outputac", content, 0666)
+
+	stdout.Reset()
+	exitCode, err = runVerify([]string{"--manifest", "output.json"}, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != verifyExitCode(split.ChunkMissing)|verifyExitCode(split.ChunkCorrupted) {
+		t.Fatal("Incorrect exit code. Expected 18, got ", exitCode)
+	}
+	if !strings.Contains(stdout.String(), "chunk 1 (outputab): missing") || !strings.Contains(stdout.String(), "chunk 2 (outputac): corrupted") {
+		t.Fatal("Incorrect report: ", stdout.String())
+	}
+
+	// Without the manifest the chunks are joined up to the first missing one.
+	stdout.Reset()
+	exitCode, err = runVerify([]string{"--original", "testfile.txt", "output"}, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != verifyExitCode(split.ChunkMissing) {
+		t.Fatal("Incorrect exit code. Expected 2, got ", exitCode)
+	}
+}
+
+func TestParseVerifyFlags(t *testing.T) {
+	testCases := []struct {
+		args         []string
+		manifestName string
+		originalName string
+		prefix       string
+		err          error
+	}{
+		{[]string{"--manifest", "output.json"}, "output.json", "", "", nil},
+		{[]string{"--original", "-", "-n", "r/3", "-d", "output"}, "", "-", "output", nil},
+		{[]string{"output"}, "", "", "", fmt.Errorf(verifyNothingErrorMsg)},
+		{[]string{"--original", "-", "output", "extra"}, "", "", "", fmt.Errorf(invalidArgumentErrorMsg, 2)},
+	}
+
+	for _, tc := range testCases {
+		parsed, err := ParseVerifyFlags(tc.args)
+		if tc.err != nil {
+			if err == nil || err.Error() != tc.err.Error() {
+				t.Errorf("Expected error %v but got %v for args %v", tc.err, err, tc.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected no error but got %v for args %v", err, tc.args)
+		}
+		if parsed.manifestName != tc.manifestName || parsed.originalName != tc.originalName || parsed.options.Prefix != tc.prefix {
+			t.Errorf("Expected %s, %s, %s but got %s, %s, %s for args %v", tc.manifestName, tc.originalName, tc.prefix, parsed.manifestName, parsed.originalName, parsed.options.Prefix, tc.args)
+		}
+	}
+}
+
+func TestRunVerifyKeepsManifestProblems(t *testing.T) {
+
+	defer deleteOutputFiles()
+	testFile, err := os.Create("testfile.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		testFile.Close()
+		os.Remove(testFile.Name())
+	}()
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintln(testFile, "line", i)
+	}
+
+	os.Args = []string{"-test.v", "-n", "r/4", "--manifest", "output.json", "testfile.txt", "output"}
+	main()
+
+	// Append a line to chunk 1.
+	content, _ := os.ReadFile("outputab")
+	os.WriteFile("outputab", append(content, "line 101\n"...), 0666)
+
+	var stdout bytes.Buffer
+	exitCode, err := runVerify([]string{"--manifest", "output.json", "--original", "testfile.txt"}, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != verifyExitCode(split.ChunkExtra)|verifyExitCode(split.ChunkResized) {
+		t.Fatal("Incorrect exit code. Expected 12, got ", exitCode, stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "chunk 1 (outputab): resized") || !strings.Contains(stdout.String(), "chunk 1: extra") {
+		t.Fatal("Incorrect report: ", stdout.String())
+	}
+}
+
+func TestRunVerifyDecompressFromManifest(t *testing.T) {
+
+	defer deleteOutputFiles()
+	var data bytes.Buffer
+	writer := gzip.NewWriter(&data)
+	for i := 1; i <= 1000; i++ {
+		fmt.Fprintln(writer, "line", i)
+	}
+	writer.Close()
+	if err := os.WriteFile("testfile.txt.gz", data.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("testfile.txt.gz")
+
+	for _, args := range [][]string{
+		{"-b", "1K"},
+		{"-l", "300", "--decompress"},
+	} {
+		os.Args = append(append([]string{"-test.v"}, args...), "--manifest", "output.json", "testfile.txt.gz", "output")
+		main()
+
+		var stdout bytes.Buffer
+		exitCode, err := runVerify([]string{"--manifest", "output.json", "--original", "testfile.txt.gz"}, &stdout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exitCode != 0 {
+			t.Fatal("Args: ", args, ", Expected no problem, got ", exitCode, stdout.String())
+		}
+		deleteOutputFiles()
+	}
+}
+
+func TestRunVerifyRoundRobinMissing(t *testing.T) {
+
+	defer deleteOutputFiles()
+	testFile, err := os.Create("testfile.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		testFile.Close()
+		os.Remove(testFile.Name())
+	}()
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintln(testFile, "line", i)
+	}
+
+	os.Args = []string{"-test.v", "-n", "r/4", "testfile.txt", "output"}
+	main()
+	os.Remove("outputac")
+
+	var stdout bytes.Buffer
+	exitCode, err := runVerify([]string{"-n", "r/4", "--original", "testfile.txt", "output"}, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != verifyExitCode(split.ChunkMissing) || !strings.HasPrefix(stdout.String(), "chunk 2: missing") {
+		t.Fatal("Expected chunk 2 missing, got ", exitCode, stdout.String())
+	}
+}
@@ -0,0 +1,301 @@
+package split
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+)
+
+// Codec compresses every chunk written through a CompressSink.
+type Codec struct {
+	// Extension is appended to the chunk name, such as ".gz".
+	Extension string
+	// NewWriter returns a writer that compresses into writer. Closing it
+	// must flush the compressed data without closing writer. The size limit
+	// after compression also needs the writer to have a Flush() error method.
+	NewWriter func(writer io.Writer) (io.WriteCloser, error)
+	// Overhead is an upper bound of the bytes the codec adds to one flushed
+	// block of at most compressedBlockSize bytes, including its header and
+	// trailer. It keeps chunks under the size limit after compression.
+	Overhead int64
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		"gzip": {
+			Extension: ".gz",
+			NewWriter: func(writer io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriter(writer), nil
+			},
+			Overhead: 64,
+		},
+		"zlib": {
+			Extension: ".zz",
+			NewWriter: func(writer io.Writer) (io.WriteCloser, error) {
+				return zlib.NewWriter(writer), nil
+			},
+			Overhead: 64,
+		},
+	}
+)
+
+// RegisterCodec makes a codec available to Options.Compress under name.
+// Registering an existing name replaces it.
+func RegisterCodec(name string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[name] = codec
+}
+
+// LookupCodec returns the codec registered under name.
+func LookupCodec(name string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return Codec{}, fmt.Errorf(unknownCodecErrorMsg, name)
+	}
+	return codec, nil
+}
+
+// compressedBlockSize is the most input written between two flushes when the
+// size limit applies after compression.
+const compressedBlockSize = 16 * 1024
+
+// CompressSink compresses every chunk before passing it to sink.
+type CompressSink struct {
+	sink  ChunkSink
+	codec Codec
+}
+
+// NewCompressSink returns a CompressSink that compresses with codec into sink.
+// The sink should name chunks with the codec extension, see NewExtensionFileNameCreater.
+func NewCompressSink(sink ChunkSink, codec Codec) CompressSink {
+	return CompressSink{sink, codec}
+}
+
+func (sink CompressSink) Open(index int) (io.WriteCloser, error) {
+	outFile, err := sink.sink.Open(index)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.New()
+	counter := &countingWriter{writer: io.MultiWriter(outFile, digest)}
+	compressor, err := sink.codec.NewWriter(counter)
+	if err != nil {
+		outFile.Close()
+		return nil, fmt.Errorf(compressErrorMsg, err)
+	}
+	return &compressedWriteCloser{compressor, counter, digest, outFile}, nil
+}
+
+func (sink CompressSink) checkChunks(count int64) error {
+	return checkChunkCount(sink.sink, count)
+}
+
+// compressingSink is implemented by sinks that compress, so that the byte
+// splitters can apply their size limit after compression.
+type compressingSink interface {
+	ChunkSink
+	// Overhead is the Overhead of the codec.
+	Overhead() int64
+	// CompressedSize returns the compressed size of reader, flushed every
+	// compressedBlockSize bytes as the chunks are.
+	CompressedSize(reader io.Reader) (int64, error)
+}
+
+func (sink CompressSink) Overhead() int64 {
+	return sink.codec.Overhead
+}
+
+func (sink CompressSink) CompressedSize(reader io.Reader) (int64, error) {
+	counter := &countingWriter{writer: io.Discard}
+	compressor, err := sink.codec.NewWriter(counter)
+	if err != nil {
+		return 0, fmt.Errorf(compressErrorMsg, err)
+	}
+	flusher, ok := compressor.(interface{ Flush() error })
+	if !ok {
+		return 0, fmt.Errorf(compressFlushErrorMsg)
+	}
+	buffer := make([]byte, compressedBlockSize)
+	for {
+		n, err := io.ReadFull(reader, buffer)
+		if n > 0 {
+			if _, err := compressor.Write(buffer[:n]); err != nil {
+				return 0, fmt.Errorf(compressErrorMsg, err)
+			}
+			if err := flusher.Flush(); err != nil {
+				return 0, fmt.Errorf(compressErrorMsg, err)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf(fileReadErrorMsg, err)
+		}
+	}
+	if err := compressor.Close(); err != nil {
+		return 0, fmt.Errorf(compressErrorMsg, err)
+	}
+	return counter.count, nil
+}
+
+// compressedChunk is a chunk opened by a compressingSink, which the byte
+// splitters flush block by block to apply their size limit after compression.
+type compressedChunk interface {
+	io.WriteCloser
+	// Flush writes the pending compressed data to the chunk.
+	Flush() error
+	// CompressedSize is the number of compressed bytes written to the chunk.
+	CompressedSize() int64
+}
+
+// compressedWriteCloser compresses into a chunk and keeps count of the
+// compressed bytes written to it so far.
+type compressedWriteCloser struct {
+	compressor io.WriteCloser
+	counter    *countingWriter
+	digest     hash.Hash
+	outFile    io.WriteCloser
+}
+
+func (w *compressedWriteCloser) Write(p []byte) (int, error) {
+	return w.compressor.Write(p)
+}
+
+// Flush writes the pending compressed data to the chunk.
+func (w *compressedWriteCloser) Flush() error {
+	flusher, ok := w.compressor.(interface{ Flush() error })
+	if !ok {
+		return fmt.Errorf(compressFlushErrorMsg)
+	}
+	return flusher.Flush()
+}
+
+// CompressedSize is the number of compressed bytes written to the chunk.
+func (w *compressedWriteCloser) CompressedSize() int64 {
+	return w.counter.count
+}
+
+// Name is the name of the chunk, when it has one.
+func (w *compressedWriteCloser) Name() string {
+	if named, ok := w.outFile.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return ""
+}
+
+func (w *compressedWriteCloser) storedDigest() (int64, []byte) {
+	return w.counter.count, w.digest.Sum(nil)
+}
+
+func (w *compressedWriteCloser) Close() error {
+	err := w.compressor.Close()
+	if closeErr := w.outFile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
+
+// ExtensionFileNameCreater appends an extension to the names of another FileNameCreater.
+type ExtensionFileNameCreater struct {
+	fileNameCreater FileNameCreater
+	extension       string
+}
+
+// NewExtensionFileNameCreater returns a FileNameCreater that appends extension
+// to the names of fileNameCreater.
+func NewExtensionFileNameCreater(fileNameCreater FileNameCreater, extension string) ExtensionFileNameCreater {
+	return ExtensionFileNameCreater{fileNameCreater, extension}
+}
+
+func (fileNameCreater ExtensionFileNameCreater) checkChunks(count int64) error {
+	return checkChunkCount(fileNameCreater.fileNameCreater, count)
+}
+
+func (fileNameCreater ExtensionFileNameCreater) Create(fileNumber int) (string, error) {
+	fileName, err := fileNameCreater.fileNameCreater.Create(fileNumber)
+	if err != nil {
+		return "", err
+	}
+	return fileName + fileNameCreater.extension, nil
+}
+
+// writeCompressedChunkBySize copies input to the chunk index of sink until the
+// compressed chunk would exceed limit bytes. It flushes the compressor after
+// every block, so that the compressed size is known before the next block is
+// written. It reports whether the end of the input has been reached.
+func writeCompressedChunkBySize(reader io.Reader, sink compressingSink, index int, limit int64) (bool, error) {
+
+	buffer := make([]byte, compressedBlockSize)
+
+	var outFile compressedChunk
+	for {
+		// Leave room for the bytes the codec adds to the next block.
+		room := limit - sink.Overhead()
+		if outFile != nil {
+			room -= outFile.CompressedSize()
+		}
+		if room <= 0 {
+			if outFile == nil {
+				return false, fmt.Errorf(compressLimitTooSmallErrorMsg, limit)
+			}
+			return false, closeChunk(outFile)
+		}
+		if room > int64(len(buffer)) {
+			room = int64(len(buffer))
+		}
+
+		n, err := io.ReadFull(reader, buffer[:room])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			closeChunk(outFile)
+			return false, fmt.Errorf(fileReadErrorMsg, err)
+		}
+		fileEnd := err != nil
+
+		if n > 0 {
+			if outFile == nil {
+				chunk, err := sink.Open(index)
+				if err != nil {
+					return false, err
+				}
+				var ok bool
+				if outFile, ok = chunk.(compressedChunk); !ok {
+					chunk.Close()
+					return false, fmt.Errorf(compressFlushErrorMsg)
+				}
+			}
+			if _, err := outFile.Write(buffer[:n]); err != nil {
+				closeChunk(outFile)
+				return false, fmt.Errorf(fileWriteErrorMsg, err)
+			}
+			if err := outFile.Flush(); err != nil {
+				closeChunk(outFile)
+				return false, fmt.Errorf(fileWriteErrorMsg, err)
+			}
+		}
+
+		if fileEnd {
+			return true, closeChunk(outFile)
+		}
+	}
+}
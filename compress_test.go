@@ -0,0 +1,238 @@
+package split
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func gunzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func randomBytes(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	return data
+}
+
+func TestCompressSinkWithLineSplitter(t *testing.T) {
+	var lines strings.Builder
+	for i := 1; i <= 2007; i++ {
+		fmt.Fprintln(&lines, "line", i)
+	}
+
+	codec, err := LookupCodec("gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memory := &MemorySink{}
+	err = LineSplitter{separateLineNumber: 1000}.Split(strings.NewReader(lines.String()), NewCompressSink(memory, codec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(memory.Chunks) != 3 {
+		t.Fatal("Incorrect number of chunks. Expected 3, got ", len(memory.Chunks))
+	}
+	var output []byte
+	for i, expectedLines := range []int{1000, 1000, 7} {
+		content := gunzip(t, memory.Chunks[i].Bytes())
+		if countLinesByByte(content) != expectedLines {
+			t.Fatal("Chunk ", i, " has incorrect number of lines. Expected ", expectedLines, ", got ", countLinesByByte(content))
+		}
+		output = append(output, content...)
+	}
+	if string(output) != lines.String() {
+		t.Fatal("Incorrect chunk content.")
+	}
+}
+
+func TestNewChunkSinkCompressAppendsExtension(t *testing.T) {
+
+	defer deleteOutputFiles()
+	options := Options{Bytes: "1k", Compress: "zlib", Prefix: "output"}
+	splitter, err := NewSplitter(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewChunkSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := randomBytes(2500)
+	if err := splitter.Split(bytes.NewReader(data), sink); err != nil {
+		t.Fatal(err)
+	}
+
+	var output []byte
+	for _, name := range []string{"outputaa.zz", "outputab.zz", "outputac.zz"} {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(name, " was not created.")
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output = append(output, decompressed...)
+	}
+	if countFiles() != 3 {
+		t.Fatal("Incorrect number of output files.")
+	}
+	if !bytes.Equal(output, data) {
+		t.Fatal("Incorrect output file content.")
+	}
+}
+
+func TestByteSplitterLimitAfterCompression(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		// Random data does not compress, so every chunk is close to the limit.
+		{"random", randomBytes(100 * 1024)},
+		// Repeated data compresses well, so a chunk holds much more input than the limit.
+		{"repeated", bytes.Repeat([]byte("line of a log file\n"), 20000)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			codec, _ := LookupCodec("gzip")
+			memory := &MemorySink{}
+			splitter := ByteSplitter{separateByteStr: "8k", afterCompression: true}
+			if err := splitter.Split(bytes.NewReader(tc.data), NewCompressSink(memory, codec)); err != nil {
+				t.Fatal(err)
+			}
+
+			var output []byte
+			for i, chunk := range memory.Chunks {
+				if chunk.Len() > 8*1024 {
+					t.Fatal("Chunk ", i, " is larger than the limit after compression: ", chunk.Len())
+				}
+				output = append(output, gunzip(t, chunk.Bytes())...)
+			}
+			if !bytes.Equal(output, tc.data) {
+				t.Fatal("Incorrect chunk content.")
+			}
+			if tc.name == "repeated" && len(memory.Chunks) != 1 {
+				t.Fatal("Compressible data should fit in one chunk, got ", len(memory.Chunks))
+			}
+		})
+	}
+}
+
+func TestPieceByteSplitterLimitAfterCompression(t *testing.T) {
+	// Half of the input compresses well, half does not.
+	data := append(bytes.Repeat([]byte("0123456789"), 10000), randomBytes(100000)...)
+
+	codec, _ := LookupCodec("gzip")
+	memory := &MemorySink{}
+	splitter := PieceSplitter{chunkStr: "4", afterCompression: true}
+	if err := splitter.Split(bytes.NewReader(data), NewCompressSink(memory, codec)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(memory.Chunks) != 4 {
+		t.Fatal("Incorrect number of chunks. Expected 4, got ", len(memory.Chunks))
+	}
+	var output []byte
+	for _, chunk := range memory.Chunks {
+		output = append(output, gunzip(t, chunk.Bytes())...)
+	}
+	if !bytes.Equal(output, data) {
+		t.Fatal("Incorrect chunk content.")
+	}
+	// The compressible half ends up in the first chunk instead of two.
+	if memory.Chunks[0].Len() > 30000 || len(gunzip(t, memory.Chunks[0].Bytes())) < 100000 {
+		t.Fatal("The first chunk is not balanced by compressed size: ", memory.Chunks[0].Len())
+	}
+}
+
+func TestLimitAfterCompressionErrors(t *testing.T) {
+	codec, _ := LookupCodec("gzip")
+
+	err := ByteSplitter{separateByteStr: "1k", afterCompression: true}.Split(strings.NewReader("data"), &MemorySink{})
+	if err == nil || err.Error() != compressLimitSinkErrorMsg {
+		t.Error("Expected ", compressLimitSinkErrorMsg, ", got ", err)
+	}
+
+	err = ByteSplitter{separateByteStr: "10", afterCompression: true}.Split(strings.NewReader("data"), NewCompressSink(&MemorySink{}, codec))
+	if err == nil || err.Error() != fmt.Sprintf(compressLimitTooSmallErrorMsg, 10) {
+		t.Error("Expected ", fmt.Sprintf(compressLimitTooSmallErrorMsg, 10), ", got ", err)
+	}
+
+	err = PieceSplitter{chunkStr: "l/3", afterCompression: true}.Split(strings.NewReader("data"), NewCompressSink(&MemorySink{}, codec))
+	if err == nil || err.Error() != compressLimitModeErrorMsg {
+		t.Error("Expected ", compressLimitModeErrorMsg, ", got ", err)
+	}
+
+	_, err = NewSplitter(Options{Lines: 10, Compress: "gzip", LimitAfterCompression: true})
+	if err == nil || err.Error() != compressLimitModeErrorMsg {
+		t.Error("Expected ", compressLimitModeErrorMsg, ", got ", err)
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec("upper", Codec{
+		Extension: ".up",
+		NewWriter: func(writer io.Writer) (io.WriteCloser, error) {
+			return nopWriteCloser{upperWriter{writer}}, nil
+		},
+	})
+	defer func() {
+		codecsMu.Lock()
+		delete(codecs, "upper")
+		codecsMu.Unlock()
+	}()
+
+	options := Options{Compress: "upper", Prefix: "output"}
+	sink, err := NewChunkSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer deleteOutputFiles()
+	if err := (LineSplitter{separateLineNumber: 1}).Split(strings.NewReader("a\nb\n"), sink); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile("outputab.up")
+	if err != nil {
+		t.Fatal("outputab.up was not created.")
+	}
+	if string(content) != "B\n" {
+		t.Fatal("Incorrect output file content. Expected B, got ", string(content))
+	}
+
+	if _, err := LookupCodec("unknown"); err == nil || err.Error() != fmt.Sprintf(unknownCodecErrorMsg, "unknown") {
+		t.Fatal("Expected an unknown codec error, got ", err)
+	}
+}
+
+type upperWriter struct {
+	writer io.Writer
+}
+
+func (w upperWriter) Write(p []byte) (int, error) {
+	return w.writer.Write(bytes.ToUpper(p))
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package split
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxCopyFileRange is the most bytes asked of a single copy_file_range call.
+const maxCopyFileRange = 1 << 30
+
+// copyFileRange copies up to size bytes of file from offset to writer with
+// copy_file_range, so that the data does not go through user space and can be
+// shared by file systems with reflinks. It returns how much it copied, which
+// is less than size when writer is not a file, the kernel or the file systems
+// cannot copy between them, or the file ends first. The caller copies the rest
+// with read and write, which also reports the errors.
+func copyFileRange(writer io.Writer, file *os.File, offset int64, size int64) int64 {
+	outFile, ok := writer.(*os.File)
+	if !ok {
+		return 0
+	}
+	in, err := file.SyscallConn()
+	if err != nil {
+		return 0
+	}
+	out, err := outFile.SyscallConn()
+	if err != nil {
+		return 0
+	}
+
+	var copied int64
+	in.Control(func(inFd uintptr) {
+		out.Control(func(outFd uintptr) {
+			for copied < size {
+				// The output offset is the one of outFile, so that writes can follow.
+				n, err := unix.CopyFileRange(int(inFd), &offset, int(outFd), nil, int(min(size-copied, maxCopyFileRange)), 0)
+				if err == unix.EINTR {
+					continue
+				}
+				if err != nil || n == 0 {
+					return
+				}
+				copied += int64(n)
+			}
+		})
+	})
+	return copied
+}
@@ -0,0 +1,53 @@
+//go:build linux
+
+package split
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyFileRange(t *testing.T) {
+	data := strings.Repeat("0123456789", 1000)
+	input, err := os.CreateTemp("", "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(input.Name())
+	defer input.Close()
+	if _, err := input.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	if _, err := output.WriteString("head:"); err != nil {
+		t.Fatal(err)
+	}
+	// The copy goes on from the offset of the output, and stops at the end of the input.
+	copied := copyFileRange(output, input, 9995, 10)
+	if copied != 5 {
+		t.Fatal("Incorrect copied size. Expected 5, got ", copied)
+	}
+	if _, err := output.WriteString(":tail"); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(output.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "head:56789:tail" {
+		t.Fatal("Incorrect content. Expected head:56789:tail, got ", string(content))
+	}
+
+	// Nothing is copied to a writer that is not a file.
+	if copied := copyFileRange(&bytes.Buffer{}, input, 0, 10); copied != 0 {
+		t.Fatal("Incorrect copied size. Expected 0, got ", copied)
+	}
+}
@@ -0,0 +1,116 @@
+package split
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// decompressPeekSize is the number of bytes read to detect the input format.
+// Besides the magic bytes, zlib input is confirmed by inflating this prefix.
+const decompressPeekSize = 512
+
+var (
+	gzipMagic       = []byte{0x1f, 0x8b, 0x08}
+	bzip2Magic      = []byte("BZh")
+	bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	bzip2EndMagic   = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+)
+
+// NewDecompressReader returns a reader of the decompressed content of reader
+// when it starts with the magic bytes of gzip, bzip2 or zlib. Other input is
+// returned as it is, so a seekable reader stays seekable and the piece
+// splitters do not have to spool it.
+func NewDecompressReader(reader io.Reader) (io.Reader, error) {
+	decompressed, _, err := decompressReader(reader)
+	return decompressed, err
+}
+
+// decompressReader is NewDecompressReader that also returns the format the
+// input is decompressed from, "gzip", "bzip2" or "zlib", or "" for raw input.
+func decompressReader(reader io.Reader) (io.Reader, string, error) {
+	var header []byte
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			header = make([]byte, decompressPeekSize)
+			n, err := io.ReadFull(seeker, header)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return nil, "", fmt.Errorf(fileReadErrorMsg, err)
+			}
+			header = header[:n]
+			if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+				return nil, "", fmt.Errorf(fileReadErrorMsg, err)
+			}
+		}
+	}
+	if header == nil {
+		buffered := bufio.NewReaderSize(reader, decompressPeekSize)
+		peeked, err := buffered.Peek(decompressPeekSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, "", fmt.Errorf(fileReadErrorMsg, err)
+		}
+		header = peeked
+		reader = buffered
+	}
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		decompressed, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, "", fmt.Errorf(decompressErrorMsg, err)
+		}
+		return decompressed, "gzip", nil
+	case isBzip2(header):
+		return bzip2.NewReader(reader), "bzip2", nil
+	case isZlib(header):
+		decompressed, err := zlib.NewReader(reader)
+		if err != nil {
+			return nil, "", fmt.Errorf(decompressErrorMsg, err)
+		}
+		return decompressed, "zlib", nil
+	}
+	return reader, "", nil
+}
+
+func isBzip2(header []byte) bool {
+	if len(header) < 10 || !bytes.HasPrefix(header, bzip2Magic) || header[3] < '1' || header[3] > '9' {
+		return false
+	}
+	return bytes.Equal(header[4:10], bzip2BlockMagic) || bytes.Equal(header[4:10], bzip2EndMagic)
+}
+
+// isZlib checks the zlib header and, since text such as "x^" also has a
+// valid header, that the rest of header inflates without an error.
+func isZlib(header []byte) bool {
+	if len(header) < 2 {
+		return false
+	}
+	cmf, flg := header[0], header[1]
+	if cmf&0x0f != 8 || cmf>>4 > 7 || flg&0x20 != 0 || (uint16(cmf)<<8|uint16(flg))%31 != 0 {
+		return false
+	}
+	decompressed, err := zlib.NewReader(bytes.NewReader(header))
+	if err != nil {
+		return false
+	}
+	_, err = io.Copy(io.Discard, decompressed)
+	return err == nil || err == io.ErrUnexpectedEOF
+}
+
+// decompressSplitter decompresses the input before passing it to splitter.
+type decompressSplitter struct {
+	splitter FileSplitter
+}
+
+func (s decompressSplitter) Split(reader io.Reader, sink ChunkSink) error {
+	decompressed, err := NewDecompressReader(reader)
+	if err != nil {
+		return err
+	}
+	return s.splitter.Split(decompressed, sink)
+}
@@ -0,0 +1,201 @@
+package split
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bzip2Lines is "line 1\n" to "line 100\n" compressed by bzip2.
+const bzip2Lines = "425a683931415926535926c5daaa0001285900001040007fe002253000c0c348983fd55080a60009a08a80002fe4b633adf1df9b2e0343a03218060190e80d0b80d9600e2001ec9525003be4f4039550b80dca83a0352a06019950190c4a8068752a00d97950038b554b00254fd25495e041e820f8107e2ee48a70a1204d8bb554"
+
+func hundredLines() string {
+	var lines strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintln(&lines, "line", i)
+	}
+	return lines.String()
+}
+
+func compressedHundredLines(t *testing.T) map[string][]byte {
+	t.Helper()
+	var gzipped, zlibbed bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write([]byte(hundredLines()))
+	gzipWriter.Close()
+	zlibWriter := zlib.NewWriter(&zlibbed)
+	zlibWriter.Write([]byte(hundredLines()))
+	zlibWriter.Close()
+	bzipped, err := hex.DecodeString(bzip2Lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string][]byte{
+		"gzip":  gzipped.Bytes(),
+		"zlib":  zlibbed.Bytes(),
+		"bzip2": bzipped,
+	}
+}
+
+func TestNewDecompressReader(t *testing.T) {
+	for format, data := range compressedHundredLines(t) {
+		readers := map[string]io.Reader{
+			"seekable": bytes.NewReader(data),
+			"stream":   io.MultiReader(bytes.NewReader(data)),
+		}
+		for kind, reader := range readers {
+			decompressed, err := NewDecompressReader(reader)
+			if err != nil {
+				t.Fatal(format, kind, err)
+			}
+			content, err := io.ReadAll(decompressed)
+			if err != nil {
+				t.Fatal(format, kind, err)
+			}
+			if string(content) != hundredLines() {
+				t.Errorf("Format: %s, %s, Incorrect decompressed content.", format, kind)
+			}
+		}
+	}
+}
+
+func TestNewDecompressReaderKeepsRawInput(t *testing.T) {
+	testCases := []string{
+		"",
+		"plain text\n",
+		// A valid zlib header that is not followed by deflate data.
+		"x^ is not zlib\n",
+		"BZh9 is not bzip2\n",
+		"\x1f\x8b",
+	}
+
+	for _, tc := range testCases {
+		reader := strings.NewReader(tc)
+		got, err := NewDecompressReader(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != io.Reader(reader) {
+			t.Errorf("Input: %q, Expected the seekable reader itself, Got: %T", tc, got)
+		}
+		content, err := io.ReadAll(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != tc {
+			t.Errorf("Input: %q, Got: %q", tc, content)
+		}
+
+		stream, err := NewDecompressReader(io.MultiReader(strings.NewReader(tc)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err = io.ReadAll(stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != tc {
+			t.Errorf("Input: %q, Got: %q from a stream", tc, content)
+		}
+	}
+}
+
+func TestDecompressSplitterCountsDecompressedLines(t *testing.T) {
+	for format, data := range compressedHundredLines(t) {
+		for _, options := range []Options{{Lines: 30}, {Chunks: "l/4"}, {Chunks: "r/4"}} {
+			options.Decompress = true
+			splitter, err := NewSplitter(options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			memory := &MemorySink{}
+			if err := splitter.Split(bytes.NewReader(data), memory); err != nil {
+				t.Fatal(format, err)
+			}
+			if len(memory.Chunks) != 4 {
+				t.Errorf("Format: %s, Options: %+v, Expected 4 chunks, Got: %d", format, options, len(memory.Chunks))
+			}
+			total := 0
+			for _, chunk := range memory.Chunks {
+				total += countLinesByByte(chunk.Bytes())
+			}
+			if total != 100 {
+				t.Errorf("Format: %s, Options: %+v, Expected 100 lines, Got: %d", format, options, total)
+			}
+		}
+	}
+}
+
+func TestDecompressSplitterFromFile(t *testing.T) {
+
+	defer deleteOutputFiles()
+	testFile, err := os.CreateTemp("", "testfile.txt.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		testFile.Close()
+		os.Remove(testFile.Name())
+	}()
+	if _, err := testFile.Write(compressedHundredLines(t)["gzip"]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testFile.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	options := Options{Chunks: "3", Decompress: true, Prefix: "output"}
+	splitter, err := NewSplitter(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewChunkSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := splitter.Split(testFile, sink); err != nil {
+		t.Fatal(err)
+	}
+
+	var output []byte
+	for _, name := range []string{"outputaa", "outputab", "outputac"} {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(name, " was not created.")
+		}
+		output = append(output, content...)
+	}
+	if string(output) != hundredLines() {
+		t.Fatal("Incorrect output file content.")
+	}
+}
+
+func TestManifestRecordsDecompression(t *testing.T) {
+	data := compressedHundredLines(t)
+	testCases := []struct {
+		input      []byte
+		decompress bool
+		expected   string
+		size       int
+	}{
+		{data["gzip"], true, "gzip", len(hundredLines())},
+		{data["bzip2"], true, "bzip2", len(hundredLines())},
+		{data["gzip"], false, "", len(data["gzip"])},
+		{[]byte(hundredLines()), true, "", len(hundredLines())},
+	}
+
+	for _, tc := range testCases {
+		dir := t.TempDir()
+		manifest := splitWithManifest(t, Options{Bytes: "1K", Decompress: tc.decompress, Prefix: filepath.Join(dir, "output")}, bytes.NewReader(tc.input))
+		if manifest.Input.Decompressed != tc.expected || manifest.Input.Size != int64(tc.size) {
+			t.Errorf("Decompress: %t, Expected: %q, %d, Got: %+v", tc.decompress, tc.expected, tc.size, manifest.Input)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package split
+
+const (
+	tooBigFileNumberErrorMsg        = "fileNumber is too big"
+	negativeDigitErrorMsg           = "digit is negative"
+	negativeFileNumberErrorMsg      = "fileNumber is negative"
+	maxMemoryLimitExceededErrorMsg  = "memory limit exceeded"
+	createFileErrorMsg              = "failed to create the output file:%w"
+	renameFileErrorMsg              = "failed to rename the output file:%w"
+	outputDirErrorMsg               = "failed to create the output directory:%w"
+	outputDirNotWritableErrorMsg    = "the output directory is not writable:%w"
+	additionalSuffixInvalidErrorMsg = "additional suffix contains a directory separator:%s"
+	fileWriteErrorMsg               = "failed to write to the output file:%w"
+	fileReadErrorMsg                = "failed to read from the input file:%w"
+	fileCloseErrorMsg               = "failed to close the output file:%w"
+	spoolFileErrorMsg               = "failed to spool the input to a temporary file:%w"
+	separateByteInvalidErrorMsg     = "separate byte is invalid"
+	separateLineInvalidErrorMsg     = "separate line number is invalid"
+	chunkFormatInvalidErrorMsg      = "chunk format is invalid"
+	separatorInvalidErrorMsg        = "separator is invalid:%s"
+	maxMemoryInvalidErrorMsg        = "max memory is invalid"
+	parallelInvalidErrorMsg         = "parallel is negative"
+	tooManyModeErrorMsg             = "only one of Lines, Bytes, LineBytes, Chunks can be set"
+	filterStartErrorMsg             = "failed to start the filter for chunk %d:%w"
+	filterExitErrorMsg              = "filter failed for chunk %d:%w"
+	unknownCodecErrorMsg            = "unknown compression codec:%s"
+	chunkOpenErrorMsg               = "failed to open the chunk:%w"
+	joinErrorMsg                    = "failed to join chunk %d:%w"
+	roundRobinInconsistentErrorMsg  = "round robin chunk %d has more lines than the chunks before it"
+	chunkMissingErrorMsg            = "chunk %d of %d is missing"
+	manifestReadErrorMsg            = "failed to read the manifest:%w"
+	manifestWriteErrorMsg           = "failed to write the manifest:%w"
+	decompressErrorMsg              = "failed to decompress the input file:%w"
+	compressErrorMsg                = "failed to compress the output file:%w"
+	compressFlushErrorMsg           = "compression codec cannot flush, so the size limit cannot apply after compression"
+	compressLimitSinkErrorMsg       = "the size limit after compression needs a compressing sink"
+	compressLimitModeErrorMsg       = "the size limit after compression needs -b or -n N"
+	compressLimitTooSmallErrorMsg   = "size limit %d is too small for the compression overhead"
+	templateInvalidErrorMsg         = "name template is invalid:%s"
+	templateTotalUnknownErrorMsg    = "the number of chunks for {total} is not known up front"
+	templateDeferredErrorMsg        = "{%s} is not known until the chunk is written"
+	templateOrderOptionErrorMsg     = "{offset}, {first_line} and {last_line} cannot be used with %s"
+	templateDeferredOptionErrorMsg  = "names known once the chunks are written cannot be used with %s"
+	contentNamesTemplateErrorMsg    = "only one of NameTemplate, ContentNames can be set"
+)
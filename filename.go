@@ -1,18 +1,39 @@
-package main
+package split
 
 import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 )
 
 type FileNameCreater interface {
 	Create(fileNumber int) (string, error)
 }
 
+// chunkLimiter is implemented by the sinks and file name creaters that can
+// name only so many chunks, so that a splitter that knows how many chunks it
+// makes can fail before writing any.
+type chunkLimiter interface {
+	checkChunks(count int64) error
+}
+
+// checkChunkCount fails when target cannot name count chunks.
+func checkChunkCount(target any, count int64) error {
+	if limiter, ok := target.(chunkLimiter); ok {
+		return limiter.checkChunks(count)
+	}
+	return nil
+}
+
 type AlphabetFileNameCreater struct {
 	digit  int
 	prefix string
+	// start is the suffix index of chunk 0, to go on from an earlier split.
+	start int
+	// auto grows the suffix once the names of digit letters run out, like
+	// GNU split without -a.
+	auto bool
 }
 
 func (fileNameCreater AlphabetFileNameCreater) Create(fileNumber int) (string, error) {
@@ -22,15 +43,21 @@ func (fileNameCreater AlphabetFileNameCreater) Create(fileNumber int) (string, e
 	if fileNumber < 0 {
 		return "", fmt.Errorf(negativeFileNumberErrorMsg)
 	}
+	fileNumber += fileNameCreater.start
+	digit, grown := fileNameCreater.digit, 0
+	if fileNameCreater.auto {
+		fileNumber, digit, grown = growSuffix(fileNumber, digit, 26)
+	}
 	var fileName string
-	if math.Pow(26, float64(fileNameCreater.digit)) <= float64(fileNumber) {
+	if math.Pow(26, float64(digit)) <= float64(fileNumber) {
 		return "", fmt.Errorf(tooBigFileNumberErrorMsg)
 	}
-	for i := 0; i < fileNameCreater.digit; i++ {
+	for i := 0; i < digit; i++ {
 		remainder := fileNumber % 26
 		fileName = string(rune(remainder+97)) + fileName
 		fileNumber = fileNumber / 26
 	}
+	fileName = strings.Repeat("z", grown) + fileName
 	if fileNameCreater.prefix != "" {
 		fileName = fileNameCreater.prefix + fileName
 	} else {
@@ -39,9 +66,21 @@ func (fileNameCreater AlphabetFileNameCreater) Create(fileNumber int) (string, e
 	return fileName, nil
 }
 
+func (fileNameCreater AlphabetFileNameCreater) checkChunks(count int64) error {
+	if !fileNameCreater.auto && math.Pow(26, float64(fileNameCreater.digit)) < float64(int64(fileNameCreater.start)+count) {
+		return fmt.Errorf(tooBigFileNumberErrorMsg)
+	}
+	return nil
+}
+
 type NumericFileNameCreater struct {
 	digit  int
 	prefix string
+	// start is the suffix index of chunk 0, to go on from an earlier split.
+	start int
+	// auto grows the suffix once the names of digit digits run out, like
+	// GNU split without -a.
+	auto bool
 }
 
 func (fileNameCreater NumericFileNameCreater) Create(fileNumber int) (string, error) {
@@ -51,14 +90,20 @@ func (fileNameCreater NumericFileNameCreater) Create(fileNumber int) (string, er
 	if fileNumber < 0 {
 		return "", fmt.Errorf(negativeFileNumberErrorMsg)
 	}
-	if math.Pow(10, float64(fileNameCreater.digit)) <= float64(fileNumber) {
+	fileNumber += fileNameCreater.start
+	digit, grown := fileNameCreater.digit, 0
+	if fileNameCreater.auto {
+		fileNumber, digit, grown = growSuffix(fileNumber, digit, 10)
+	}
+	if math.Pow(10, float64(digit)) <= float64(fileNumber) {
 		return "", fmt.Errorf(tooBigFileNumberErrorMsg)
 	}
 	var fileName string
-	for i := 0; i < fileNameCreater.digit; i++ {
+	for i := 0; i < digit; i++ {
 		fileName = strconv.Itoa(fileNumber%10) + fileName
 		fileNumber = fileNumber / 10
 	}
+	fileName = strings.Repeat("9", grown) + fileName
 	if fileNameCreater.prefix != "" {
 		fileName = fileNameCreater.prefix + fileName
 	} else {
@@ -67,3 +112,87 @@ func (fileNameCreater NumericFileNameCreater) Create(fileNumber int) (string, er
 
 	return fileName, nil
 }
+
+func (fileNameCreater NumericFileNameCreater) checkChunks(count int64) error {
+	if !fileNameCreater.auto && math.Pow(10, float64(fileNameCreater.digit)) < float64(int64(fileNameCreater.start)+count) {
+		return fmt.Errorf(tooBigFileNumberErrorMsg)
+	}
+	return nil
+}
+
+// hexDigits are the digits of the hexadecimal suffixes.
+const hexDigits = "0123456789abcdef"
+
+type HexFileNameCreater struct {
+	digit  int
+	prefix string
+	// start is the suffix index of chunk 0, to go on from an earlier split.
+	start int
+	// auto grows the suffix once the names of digit digits run out, like
+	// GNU split without -a.
+	auto bool
+}
+
+func (fileNameCreater HexFileNameCreater) Create(fileNumber int) (string, error) {
+	if fileNameCreater.digit < 1 {
+		return "", fmt.Errorf(negativeDigitErrorMsg)
+	}
+	if fileNumber < 0 {
+		return "", fmt.Errorf(negativeFileNumberErrorMsg)
+	}
+	fileNumber += fileNameCreater.start
+	digit, grown := fileNameCreater.digit, 0
+	if fileNameCreater.auto {
+		fileNumber, digit, grown = growSuffix(fileNumber, digit, 16)
+	}
+	if math.Pow(16, float64(digit)) <= float64(fileNumber) {
+		return "", fmt.Errorf(tooBigFileNumberErrorMsg)
+	}
+	var fileName string
+	for i := 0; i < digit; i++ {
+		fileName = string(hexDigits[fileNumber%16]) + fileName
+		fileNumber = fileNumber / 16
+	}
+	fileName = strings.Repeat("f", grown) + fileName
+	if fileNameCreater.prefix != "" {
+		fileName = fileNameCreater.prefix + fileName
+	} else {
+		fileName = "x" + fileName
+	}
+
+	return fileName, nil
+}
+
+func (fileNameCreater HexFileNameCreater) checkChunks(count int64) error {
+	if !fileNameCreater.auto && math.Pow(16, float64(fileNameCreater.digit)) < float64(int64(fileNameCreater.start)+count) {
+		return fmt.Errorf(tooBigFileNumberErrorMsg)
+	}
+	return nil
+}
+
+// growSuffix returns the number and the length of the suffix of chunk
+// fileNumber when the suffix grows, and how many times it grew. The names
+// of each length that start with the last digit are left out, and the
+// longer names follow with that digit in front, so that xyz is followed by
+// xzaaa and the names still sort in chunk order.
+func growSuffix(fileNumber int, digit int, base int) (int, int, int) {
+	for grown := 0; ; grown++ {
+		// The names of this length that do not start with the last digit.
+		count := float64(base-1) * math.Pow(float64(base), float64(digit-1))
+		if float64(fileNumber) < count {
+			return fileNumber, digit, grown
+		}
+		fileNumber -= int(count)
+		digit++
+	}
+}
+
+// suffixLengthFor returns the smallest suffix length that names count chunks
+// with base digits.
+func suffixLengthFor(count int64, base int) int {
+	length := 1
+	for names := int64(base); names < count; names *= int64(base) {
+		length++
+	}
+	return length
+}
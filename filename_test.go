@@ -1,4 +1,4 @@
-package main
+package split
 
 import (
 	"testing"
@@ -32,7 +32,7 @@ func TestCreateAlphabetFileName(t *testing.T) {
 
 	// Execute each test case
 	for _, tc := range testCases {
-		var fileNameCreater FileNameCreater = AlphabetFileNameCreater{tc.digit, tc.prefix}
+		var fileNameCreater FileNameCreater = AlphabetFileNameCreater{digit: tc.digit, prefix: tc.prefix}
 		got, _ := fileNameCreater.Create(tc.fileNumber)
 		if got != tc.expectedFileName {
 			t.Errorf("Input: %d, %s,%d, Expected: %s, Got: %s", tc.digit, tc.prefix, tc.fileNumber, tc.expectedFileName, got)
@@ -68,7 +68,7 @@ func TestCreateAlphabetFileNameNegativeDigitError(t *testing.T) {
 
 	// Execute each test case
 	for _, tc := range testCases {
-		var fileNameCreater FileNameCreater = AlphabetFileNameCreater{tc.digit, tc.prefix}
+		var fileNameCreater FileNameCreater = AlphabetFileNameCreater{digit: tc.digit, prefix: tc.prefix}
 		got, err := fileNameCreater.Create(tc.fileNumber)
 		if err == nil {
 			// Error should be thrown
@@ -108,7 +108,7 @@ func TestCreateAlphabetFileNameNegativeFileNumberError(t *testing.T) {
 
 	// Execute each test case
 	for _, tc := range testCases {
-		var fileNameCreater FileNameCreater = AlphabetFileNameCreater{tc.digit, tc.prefix}
+		var fileNameCreater FileNameCreater = AlphabetFileNameCreater{digit: tc.digit, prefix: tc.prefix}
 		got, err := fileNameCreater.Create(tc.fileNumber)
 		if err == nil {
 			// Error should be thrown
@@ -144,7 +144,7 @@ func TestCreateAlphabetFileNameTooBigFileNumberErrorMsg(t *testing.T) {
 
 	// Execute each test case
 	for _, tc := range testCases {
-		var fileNameCreater FileNameCreater = AlphabetFileNameCreater{tc.digit, tc.prefix}
+		var fileNameCreater FileNameCreater = AlphabetFileNameCreater{digit: tc.digit, prefix: tc.prefix}
 		got, err := fileNameCreater.Create(tc.fileNumber)
 		if err == nil {
 			// Error should be thrown
@@ -184,7 +184,7 @@ func TestCreateNumericFileName(t *testing.T) {
 
 	// Execute each test case
 	for _, tc := range testCases {
-		var fileNameCreater FileNameCreater = NumericFileNameCreater{tc.digit, tc.prefix}
+		var fileNameCreater FileNameCreater = NumericFileNameCreater{digit: tc.digit, prefix: tc.prefix}
 		got, _ := fileNameCreater.Create(tc.fileNumber)
 		if got != tc.expectedFileName {
 			t.Errorf("Input: %d, %s, %d, Expected: %s, Got: %s", tc.digit, tc.prefix, tc.fileNumber, tc.expectedFileName, got)
@@ -220,7 +220,7 @@ func TestCreateNumericFileNameNegativeDigitError(t *testing.T) {
 
 	// Execute each test case
 	for _, tc := range testCases {
-		var fileNameCreater FileNameCreater = NumericFileNameCreater{tc.digit, tc.prefix}
+		var fileNameCreater FileNameCreater = NumericFileNameCreater{digit: tc.digit, prefix: tc.prefix}
 		got, err := fileNameCreater.Create(tc.fileNumber)
 		if err == nil {
 			// Error should be thrown
@@ -260,7 +260,7 @@ func TestCreateNumericFileNameNegativeFileNumberError(t *testing.T) {
 
 	// Execute each test case
 	for _, tc := range testCases {
-		var fileNameCreater FileNameCreater = NumericFileNameCreater{tc.digit, tc.prefix}
+		var fileNameCreater FileNameCreater = NumericFileNameCreater{digit: tc.digit, prefix: tc.prefix}
 		got, err := fileNameCreater.Create(tc.fileNumber)
 		if err == nil {
 			// Error should be thrown
@@ -296,7 +296,7 @@ func TestCreateNumericFileNameTooBigFileNumberErrorMsg(t *testing.T) {
 
 	// Execute each test case
 	for _, tc := range testCases {
-		var fileNameCreater FileNameCreater = NumericFileNameCreater{tc.digit, tc.prefix}
+		var fileNameCreater FileNameCreater = NumericFileNameCreater{digit: tc.digit, prefix: tc.prefix}
 		got, err := fileNameCreater.Create(tc.fileNumber)
 		if err == nil {
 			// Error should be thrown
@@ -307,3 +307,165 @@ func TestCreateNumericFileNameTooBigFileNumberErrorMsg(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateAutoFileName(t *testing.T) {
+	testCases := []struct {
+		numeric          bool
+		digit            int
+		fileNumber       int
+		expectedFileName string
+	}{
+		{false, 2, 0, "xaa"},
+		{false, 2, 649, "xyz"},
+		{false, 2, 650, "xzaaa"},
+		{false, 2, 650 + 25*26*26 - 1, "xzyzz"},
+		{false, 2, 650 + 25*26*26, "xzzaaaa"},
+		{false, 1, 24, "xy"},
+		{false, 1, 25, "xzaa"},
+		{true, 2, 89, "x89"},
+		{true, 2, 90, "x9000"},
+		{true, 2, 989, "x9899"},
+		{true, 2, 990, "x990000"},
+	}
+
+	for _, tc := range testCases {
+		var fileNameCreater FileNameCreater = AlphabetFileNameCreater{digit: tc.digit, auto: true}
+		if tc.numeric {
+			fileNameCreater = NumericFileNameCreater{digit: tc.digit, auto: true}
+		}
+		got, err := fileNameCreater.Create(tc.fileNumber)
+		if err != nil || got != tc.expectedFileName {
+			t.Errorf("Input: %t, %d, %d, Expected: %s, Got: %s, %v", tc.numeric, tc.digit, tc.fileNumber, tc.expectedFileName, got, err)
+		}
+	}
+}
+
+func TestCreateAutoFileNameSorted(t *testing.T) {
+	for _, fileNameCreater := range []FileNameCreater{AlphabetFileNameCreater{digit: 1, auto: true}, NumericFileNameCreater{digit: 1, auto: true}} {
+		previous := ""
+		for fileNumber := 0; fileNumber < 100000; fileNumber++ {
+			got, err := fileNameCreater.Create(fileNumber)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got <= previous {
+				t.Fatalf("Names out of order: %s comes after %s", got, previous)
+			}
+			previous = got
+		}
+	}
+}
+
+func TestCheckChunks(t *testing.T) {
+	testCases := []struct {
+		target any
+		count  int64
+		err    bool
+	}{
+		{AlphabetFileNameCreater{digit: 2}, 676, false},
+		{AlphabetFileNameCreater{digit: 2}, 677, true},
+		{AlphabetFileNameCreater{digit: 2, auto: true}, 1000000, false},
+		{NumericFileNameCreater{digit: 3}, 1000, false},
+		{NumericFileNameCreater{digit: 3}, 1001, true},
+		{NumericFileNameCreater{digit: 2, start: 90}, 10, false},
+		{NumericFileNameCreater{digit: 2, start: 90}, 11, true},
+		{HexFileNameCreater{digit: 2}, 256, false},
+		{HexFileNameCreater{digit: 2, start: 1}, 256, true},
+		{NewExtensionFileNameCreater(NumericFileNameCreater{digit: 1}, ".gz"), 11, true},
+		{FileSink{AlphabetFileNameCreater{digit: 1}}, 27, true},
+		{NewManifestSink(FileSink{AlphabetFileNameCreater{digit: 1}}), 27, true},
+		{&MemorySink{}, 1000000, false},
+	}
+
+	for _, tc := range testCases {
+		err := checkChunkCount(tc.target, tc.count)
+		if tc.err && (err == nil || err.Error() != tooBigFileNumberErrorMsg) {
+			t.Errorf("Input: %T, %d, Expected error: %s, Got: %v", tc.target, tc.count, tooBigFileNumberErrorMsg, err)
+		}
+		if !tc.err && err != nil {
+			t.Errorf("Input: %T, %d, Expected no error, Got: %v", tc.target, tc.count, err)
+		}
+	}
+}
+
+func TestSuffixLengthFor(t *testing.T) {
+	testCases := []struct {
+		count    int64
+		base     int
+		expected int
+	}{
+		{1, 26, 1},
+		{26, 26, 1},
+		{27, 26, 2},
+		{676, 26, 2},
+		{677, 26, 3},
+		{100, 10, 2},
+		{101, 10, 3},
+	}
+
+	for _, tc := range testCases {
+		if got := suffixLengthFor(tc.count, tc.base); got != tc.expected {
+			t.Errorf("Input: %d, %d, Expected: %d, Got: %d", tc.count, tc.base, tc.expected, got)
+		}
+	}
+}
+
+func TestCreateHexFileName(t *testing.T) {
+	testCases := []struct {
+		digit            int
+		prefix           string
+		fileNumber       int
+		expectedFileName string
+	}{
+		{2, "", 0, "x00"},
+		{2, "", 10, "x0a"},
+		{2, "part", 255, "partff"},
+		{4, "", 4096, "x1000"},
+		{1, "h", 15, "hf"},
+	}
+
+	for _, tc := range testCases {
+		got, err := HexFileNameCreater{digit: tc.digit, prefix: tc.prefix}.Create(tc.fileNumber)
+		if err != nil || got != tc.expectedFileName {
+			t.Errorf("Input: %d, %s, %d, Expected: %s, Got: %s, %v", tc.digit, tc.prefix, tc.fileNumber, tc.expectedFileName, got, err)
+		}
+	}
+
+	if _, err := (HexFileNameCreater{digit: 2}).Create(256); err == nil || err.Error() != tooBigFileNumberErrorMsg {
+		t.Errorf("Input: 2, 256, Expected: %s, Got: %v", tooBigFileNumberErrorMsg, err)
+	}
+	if got, _ := (HexFileNameCreater{digit: 2, auto: true}).Create(240); got != "xf000" {
+		t.Errorf("Input: 2, 240, Expected: xf000, Got: %s", got)
+	}
+}
+
+func TestCreateFileNameWithStart(t *testing.T) {
+	testCases := []struct {
+		fileNameCreater  FileNameCreater
+		fileNumber       int
+		expectedFileName string
+		err              bool
+	}{
+		{NumericFileNameCreater{digit: 2, start: 5}, 0, "x05", false},
+		{NumericFileNameCreater{digit: 2, start: 5}, 94, "x99", false},
+		{NumericFileNameCreater{digit: 2, start: 5}, 95, "", true},
+		{NumericFileNameCreater{digit: 2, start: 89, auto: true}, 1, "x9000", false},
+		{HexFileNameCreater{digit: 2, start: 0x10}, 1, "x11", false},
+		{HexFileNameCreater{digit: 2, start: 0xf0}, 16, "", true},
+		{AlphabetFileNameCreater{digit: 2, start: 26}, 0, "xba", false},
+		{AlphabetFileNameCreater{digit: 2, start: 600}, 76, "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := tc.fileNameCreater.Create(tc.fileNumber)
+		if tc.err {
+			if err == nil || err.Error() != tooBigFileNumberErrorMsg {
+				t.Errorf("Input: %+v, %d, Expected: %s, Got: %s, %v", tc.fileNameCreater, tc.fileNumber, tooBigFileNumberErrorMsg, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.expectedFileName {
+			t.Errorf("Input: %+v, %d, Expected: %s, Got: %s, %v", tc.fileNameCreater, tc.fileNumber, tc.expectedFileName, got, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// FilterSink pipes every chunk into the stdin of a shell command instead of
+// creating a file, like GNU split --filter. The name the FileNameCreater
+// would have produced is passed in the FILE environment variable.
+type FilterSink struct {
+	command         string
+	fileNameCreater FileNameCreater
+	stdout          io.Writer
+}
+
+// NewFilterSink returns a FilterSink that runs command for every chunk.
+// The output of the command goes to stdout, or os.Stdout when nil.
+func NewFilterSink(command string, fileNameCreater FileNameCreater, stdout io.Writer) FilterSink {
+	if stdout == nil {
+		stdout = os.Stdout
+	} else if _, ok := stdout.(*os.File); !ok {
+		// Several filters can run at once in the round robin mode.
+		stdout = &lockedWriter{writer: stdout}
+	}
+	return FilterSink{command, fileNameCreater, stdout}
+}
+
+func (sink FilterSink) Open(index int) (io.WriteCloser, error) {
+	outputFilePath, err := sink.fileNameCreater.Create(index)
+	if err != nil {
+		return nil, err
+	}
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	cmd := exec.Command(shell, "-c", sink.command)
+	cmd.Env = append(os.Environ(), "FILE="+outputFilePath)
+	cmd.Stdout = sink.stdout
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf(filterStartErrorMsg, index, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf(filterStartErrorMsg, index, err)
+	}
+	return &filterWriteCloser{stdin: stdin, cmd: cmd, index: index, name: outputFilePath}, nil
+}
+
+func (sink FilterSink) checkChunks(count int64) error {
+	return checkChunkCount(sink.fileNameCreater, count)
+}
+
+type filterWriteCloser struct {
+	stdin  io.WriteCloser
+	cmd    *exec.Cmd
+	index  int
+	name   string
+	exited bool
+}
+
+// Name is the name passed to the command in $FILE.
+func (w *filterWriteCloser) Name() string {
+	return w.name
+}
+
+func (w *filterWriteCloser) Write(p []byte) (int, error) {
+	if w.exited {
+		return len(p), nil
+	}
+	n, err := w.stdin.Write(p)
+	if errors.Is(err, syscall.EPIPE) {
+		// The command stopped reading; its exit status is reported by Close.
+		w.exited = true
+		return len(p), nil
+	}
+	return n, err
+}
+
+func (w *filterWriteCloser) Close() error {
+	closeErr := w.stdin.Close()
+	if err := w.cmd.Wait(); err != nil {
+		return fmt.Errorf(filterExitErrorMsg, w.index, err)
+	}
+	if closeErr != nil && !errors.Is(closeErr, syscall.EPIPE) {
+		return closeErr
+	}
+	return nil
+}
+
+// lockedWriter serializes writes from several goroutines.
+type lockedWriter struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writer.Write(p)
+}
@@ -0,0 +1,94 @@
+package split
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFilterSinkWithLineSplitter(t *testing.T) {
+
+	defer deleteOutputFiles()
+	stdout := &bytes.Buffer{}
+	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
+	sink := NewFilterSink(`echo "$FILE $(wc -l)"`, fileNameCreater, stdout)
+
+	var lines strings.Builder
+	for i := 1; i <= 2007; i++ {
+		fmt.Fprintln(&lines, "line", i)
+	}
+
+	err := LineSplitter{separateLineNumber: 1000}.Split(strings.NewReader(lines.String()), sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "outputaa 1000\noutputab 1000\noutputac 7\n"
+	if stdout.String() != expected {
+		t.Fatalf("Incorrect filter output. Expected %q, got %q", expected, stdout.String())
+	}
+	if countFiles() != 0 {
+		t.Fatal("Filter created output files.")
+	}
+}
+
+func TestFilterSinkWithRoundRobinSplitter(t *testing.T) {
+
+	defer deleteOutputFiles()
+	stdout := &bytes.Buffer{}
+	fileNameCreater := NumericFileNameCreater{digit: 2, prefix: "output"}
+	sink := NewFilterSink(`echo "$FILE $(wc -l)"`, fileNameCreater, stdout)
+
+	var lines strings.Builder
+	for i := 1; i <= 2005; i++ {
+		fmt.Fprintln(&lines, "line", i)
+	}
+
+	err := PieceSplitter{chunkStr: "r/3"}.Split(strings.NewReader(lines.String()), sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The three filters run at the same time, so their output order is not fixed.
+	got := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	sort.Strings(got)
+	expected := []string{"output00 669", "output01 668", "output02 668"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatal("Incorrect filter output. Expected ", expected, ", got ", got)
+	}
+	if countFiles() != 0 {
+		t.Fatal("Filter created output files.")
+	}
+}
+
+func TestFilterSinkReportsExitStatus(t *testing.T) {
+	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
+	sink := NewFilterSink(`cat > /dev/null; test "$FILE" != outputab`, fileNameCreater, &bytes.Buffer{})
+
+	err := ByteSplitter{separateByteStr: "1k"}.Split(bytes.NewReader(make([]byte, 3000)), sink)
+	if err == nil {
+		t.Fatal("Expected an error from the failing filter.")
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatal("Expected exit status 1, got ", err)
+	}
+	expected := fmt.Errorf(filterExitErrorMsg, 1, exitErr).Error()
+	if !strings.HasSuffix(err.Error(), expected) {
+		t.Fatalf("Expected error %q, got %q", expected, err.Error())
+	}
+}
+
+func TestFilterSinkIgnoresEarlyExit(t *testing.T) {
+	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
+	sink := NewFilterSink("exit 0", fileNameCreater, &bytes.Buffer{})
+
+	err := ByteSplitter{separateByteStr: "1m"}.Split(bytes.NewReader(make([]byte, 3*1024*1024)), sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/ryuki8643/split
 
 go 1.21
+
+require golang.org/x/sys v0.30.0
@@ -0,0 +1,175 @@
+package split
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// ChunkSource opens the chunks of a split set for reading. Open returns an
+// error that matches fs.ErrNotExist when there is no chunk index.
+type ChunkSource interface {
+	Open(index int) (io.ReadCloser, error)
+}
+
+// FileSource reads every chunk from the file named by its FileNameCreater.
+type FileSource struct {
+	fileNameCreater FileNameCreater
+}
+
+// NewFileSource returns a ChunkSource that reads the files named by fileNameCreater.
+func NewFileSource(fileNameCreater FileNameCreater) FileSource {
+	return FileSource{fileNameCreater}
+}
+
+func (source FileSource) Open(index int) (io.ReadCloser, error) {
+	inputFilePath, err := source.fileNameCreater.Create(index)
+	if err != nil {
+		// Names run out where the suffix length does, so do the chunks.
+		if err.Error() == tooBigFileNumberErrorMsg {
+			return nil, fs.ErrNotExist
+		}
+		return nil, err
+	}
+	inFile, err := os.Open(inputFilePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return nil, fmt.Errorf(chunkOpenErrorMsg, err)
+	}
+	return inFile, nil
+}
+
+// Joiner reassembles the chunks of a split set into the original input.
+type Joiner struct {
+	source ChunkSource
+	// chunkNumber is the number of chunks to join. 0 joins up to the first missing chunk.
+	chunkNumber int64
+	// roundRobin re-interleaves the lines of the r/N chunks.
+	roundRobin bool
+	// separator ends the lines of the r/N chunks.
+	separator string
+}
+
+// NewJoiner returns a Joiner for the chunks of source. chunkStr is the CHUNKS
+// specification the set was split with, such as "r/3", and separatorStr its
+// line separator (-t). An empty chunkStr joins the chunks up to the first
+// missing one, in index order.
+func NewJoiner(source ChunkSource, chunkStr string, separatorStr string) (Joiner, error) {
+	separator, err := parseSeparator(separatorStr)
+	if err != nil {
+		return Joiner{}, err
+	}
+	if chunkStr == "" {
+		return Joiner{source: source, separator: lineSeparator(separator)}, nil
+	}
+	chunk, err := parseCHUNK(chunkStr)
+	if err != nil {
+		return Joiner{}, err
+	}
+	if chunk.K != 0 {
+		return Joiner{}, fmt.Errorf(chunkFormatInvalidErrorMsg)
+	}
+	return Joiner{source, chunk.N, chunk.R, lineSeparator(separator)}, nil
+}
+
+// Join writes the joined chunks to writer and returns the number of chunks
+// read. It stops at the first missing chunk. When the number of chunks is
+// known, a missing chunk is an error unless there is no chunk at all, as for
+// an empty input.
+func (j Joiner) Join(writer io.Writer) (int, error) {
+	if j.roundRobin {
+		return j.joinRoundRobin(writer)
+	}
+
+	joined := 0
+	for j.chunkNumber == 0 || int64(joined) < j.chunkNumber {
+		inFile, err := j.source.Open(joined)
+		if errors.Is(err, fs.ErrNotExist) {
+			if j.chunkNumber != 0 && joined > 0 {
+				return joined, chunkMissingError{joined, j.chunkNumber}
+			}
+			break
+		}
+		if err != nil {
+			return joined, err
+		}
+		_, err = io.Copy(writer, inFile)
+		inFile.Close()
+		if err != nil {
+			return joined, fmt.Errorf(joinErrorMsg, joined, err)
+		}
+		joined++
+	}
+	return joined, nil
+}
+
+// roundRobinInconsistentError is the error of an r/N chunk with more lines
+// than the chunks before it, which verify reports as a problem of the chunk.
+type roundRobinInconsistentError struct {
+	index int
+}
+
+func (err roundRobinInconsistentError) Error() string {
+	return fmt.Sprintf(roundRobinInconsistentErrorMsg, err.index)
+}
+
+// chunkMissingError is the error of a chunk missing from a set of a known
+// number of chunks, which verify reports as a problem of the chunk.
+type chunkMissingError struct {
+	index int
+	total int64
+}
+
+func (err chunkMissingError) Error() string {
+	return fmt.Sprintf(chunkMissingErrorMsg, err.index, err.total)
+}
+
+// joinRoundRobin takes one line from every chunk in turn, the way
+// PieceLineRoundRobinSplitter dealt them out. Every chunk is opened before
+// any line is written, so that a missing one is found first.
+func (j Joiner) joinRoundRobin(writer io.Writer) (int, error) {
+	var readers []*bufio.Reader
+	for j.chunkNumber == 0 || int64(len(readers)) < j.chunkNumber {
+		inFile, err := j.source.Open(len(readers))
+		if errors.Is(err, fs.ErrNotExist) {
+			if j.chunkNumber != 0 && len(readers) > 0 {
+				return len(readers), chunkMissingError{len(readers), j.chunkNumber}
+			}
+			break
+		}
+		if err != nil {
+			return len(readers), err
+		}
+		defer inFile.Close()
+		readers = append(readers, bufio.NewReader(inFile))
+	}
+	if len(readers) == 0 {
+		return 0, nil
+	}
+
+	for {
+		for i, reader := range readers {
+			more, err := hasLine(reader)
+			if err != nil {
+				return len(readers), fmt.Errorf(joinErrorMsg, i, err)
+			}
+			if !more {
+				// The first chunks get the extra lines, so the rest must be done too.
+				for k := i + 1; k < len(readers); k++ {
+					if _, err := readers[k].Peek(1); err != io.EOF {
+						return len(readers), roundRobinInconsistentError{k}
+					}
+				}
+				return len(readers), nil
+			}
+			if err := writeLine(writer, reader, j.separator); err != nil {
+				return len(readers), fmt.Errorf(joinErrorMsg, i, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package split
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// memorySource reads the chunks of a MemorySink back.
+type memorySource struct {
+	sink *MemorySink
+}
+
+func (source memorySource) Open(index int) (io.ReadCloser, error) {
+	if index >= len(source.sink.Chunks) || source.sink.Chunks[index] == nil {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(source.sink.Chunks[index].Bytes())), nil
+}
+
+func TestJoinerRoundTrip(t *testing.T) {
+	var lines strings.Builder
+	for i := 1; i <= 2007; i++ {
+		fmt.Fprintln(&lines, "line", i)
+	}
+
+	testCases := []struct {
+		options  Options
+		chunkStr string
+	}{
+		{Options{Lines: 300}, ""},
+		{Options{Bytes: "1k"}, ""},
+		{Options{Chunks: "5"}, "5"},
+		{Options{Chunks: "l/5"}, ""},
+		{Options{Chunks: "r/5"}, "r/5"},
+		// Fewer lines than chunks leave the last chunks empty.
+		{Options{Chunks: "r/3000"}, "r/3000"},
+		{Options{Chunks: "2500"}, "2500"},
+	}
+
+	for _, tc := range testCases {
+		splitter, err := NewSplitter(tc.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		memory := &MemorySink{}
+		if err := splitter.Split(strings.NewReader(lines.String()), memory); err != nil {
+			t.Fatal(err)
+		}
+
+		joiner, err := NewJoiner(memorySource{memory}, tc.chunkStr, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var output bytes.Buffer
+		joined, err := joiner.Join(&output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if joined != len(memory.Chunks) {
+			t.Errorf("Options: %+v, Expected %d chunks joined, Got: %d", tc.options, len(memory.Chunks), joined)
+		}
+		if output.String() != lines.String() {
+			t.Errorf("Options: %+v, Joined content is not the original.", tc.options)
+		}
+	}
+}
+
+func TestJoinerRoundTripExactBytes(t *testing.T) {
+	// CRLF lines, a lone CR, empty lines and no newline at the end.
+	var lines strings.Builder
+	for i := 1; i <= 2007; i++ {
+		fmt.Fprintf(&lines, "line %d\r\n", i)
+		if i%100 == 0 {
+			lines.WriteString("\r\n\n a\rb\n")
+		}
+	}
+	lines.WriteString("last line")
+
+	testCases := []struct {
+		options  Options
+		chunkStr string
+	}{
+		{Options{Lines: 300}, ""},
+		{Options{Lines: 1}, ""},
+		{Options{Chunks: "l/5"}, ""},
+		{Options{Chunks: "r/5"}, "r/5"},
+		{Options{Chunks: "r/3"}, "r/3"},
+	}
+
+	for _, tc := range testCases {
+		splitter, err := NewSplitter(tc.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		memory := &MemorySink{}
+		if err := splitter.Split(strings.NewReader(lines.String()), memory); err != nil {
+			t.Fatal(err)
+		}
+
+		joiner, err := NewJoiner(memorySource{memory}, tc.chunkStr, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var output bytes.Buffer
+		if _, err := joiner.Join(&output); err != nil {
+			t.Fatal(err)
+		}
+		if output.String() != lines.String() {
+			t.Errorf("Options: %+v, Joined content is not byte-identical to the original.", tc.options)
+		}
+	}
+}
+
+func TestJoinerStopsAtFirstMissingChunk(t *testing.T) {
+	memory := &MemorySink{}
+	for _, index := range []int{0, 1, 3} {
+		outFile, _ := memory.Open(index)
+		fmt.Fprintf(outFile, "chunk %d\n", index)
+	}
+
+	joiner, err := NewJoiner(memorySource{memory}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	joined, err := joiner.Join(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined != 2 || output.String() != "chunk 0\nchunk 1\n" {
+		t.Fatalf("Expected chunks 0 and 1, Got %d chunks: %q", joined, output.String())
+	}
+}
+
+func TestJoinerMissingChunk(t *testing.T) {
+	for _, chunkStr := range []string{"4", "r/4"} {
+		memory := &MemorySink{}
+		for _, index := range []int{0, 1, 3} {
+			outFile, _ := memory.Open(index)
+			fmt.Fprintf(outFile, "chunk %d\n", index)
+		}
+
+		joiner, err := NewJoiner(memorySource{memory}, chunkStr, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var output bytes.Buffer
+		if _, err := joiner.Join(&output); err == nil || err.Error() != fmt.Sprintf(chunkMissingErrorMsg, 2, 4) {
+			t.Errorf("Input: %s, Expected: %s, Got: %v", chunkStr, fmt.Sprintf(chunkMissingErrorMsg, 2, 4), err)
+		}
+		// The lines are not interleaved without all the chunks.
+		if chunkStr == "r/4" && output.Len() != 0 {
+			t.Errorf("Input: %s, Expected no output, Got: %q", chunkStr, output.String())
+		}
+	}
+
+	// No chunk at all is an empty input.
+	joiner, _ := NewJoiner(memorySource{&MemorySink{}}, "r/4", "")
+	if joined, err := joiner.Join(io.Discard); joined != 0 || err != nil {
+		t.Errorf("Expected no chunk joined, Got: %d, %v", joined, err)
+	}
+}
+
+func TestJoinerRoundRobinInconsistent(t *testing.T) {
+	memory := &MemorySink{}
+	for index, content := range []string{"a\n", "b\nc\n"} {
+		outFile, _ := memory.Open(index)
+		io.WriteString(outFile, content)
+	}
+
+	joiner, err := NewJoiner(memorySource{memory}, "r/2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = joiner.Join(io.Discard)
+	if err == nil || err.Error() != fmt.Sprintf(roundRobinInconsistentErrorMsg, 1) {
+		t.Fatal("Expected ", fmt.Sprintf(roundRobinInconsistentErrorMsg, 1), ", got ", err)
+	}
+}
+
+func TestNewJoinerRejectsK(t *testing.T) {
+	for _, chunkStr := range []string{"2/3", "r/2/3", "l/1/3", "x"} {
+		if _, err := NewJoiner(memorySource{&MemorySink{}}, chunkStr, ""); err == nil || err.Error() != chunkFormatInvalidErrorMsg {
+			t.Errorf("Input: %s, Expected: %s, Got: %v", chunkStr, chunkFormatInvalidErrorMsg, err)
+		}
+	}
+}
+
+func TestFileSourceOpen(t *testing.T) {
+
+	defer deleteOutputFiles()
+	fileNameCreater := AlphabetFileNameCreater{digit: 1, prefix: "output"}
+	outFile, err := NewFileSink(fileNameCreater).Open(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outFile.Write([]byte("chunk"))
+	outFile.Close()
+
+	source := NewFileSource(fileNameCreater)
+	inFile, err := source.Open(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(inFile)
+	inFile.Close()
+	if string(content) != "chunk" {
+		t.Fatal("Incorrect chunk content. Expected chunk, got ", string(content))
+	}
+
+	// A missing file and a file number beyond the suffix length are both missing chunks.
+	for _, index := range []int{1, 26} {
+		if _, err := source.Open(index); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Index: %d, Expected a missing chunk, Got: %v", index, err)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package split
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Manifest describes a split set: where it came from, how it was split and
+// what every chunk holds. Join and verify tooling can rely on it.
+type Manifest struct {
+	Input  ManifestInput   `json:"input"`
+	Mode   ManifestMode    `json:"mode"`
+	Suffix ManifestSuffix  `json:"suffix"`
+	Chunks []ManifestChunk `json:"chunks"`
+}
+
+// ManifestInput describes the content that was split. For compressed input
+// read with Options.Decompress, that is the decompressed content, and
+// Decompressed is the format it was decompressed from, such as "gzip".
+type ManifestInput struct {
+	Name         string `json:"name"`
+	Size         int64  `json:"size"`
+	SHA256       string `json:"sha256"`
+	Decompressed string `json:"decompressed,omitempty"`
+}
+
+// ManifestMode is the mode the input was split with. Exactly one of Lines,
+// Bytes, LineBytes and Chunks is set.
+type ManifestMode struct {
+	Lines     int64              `json:"lines,omitempty"`
+	Bytes     int64              `json:"bytes,omitempty"`
+	LineBytes int64              `json:"line_bytes,omitempty"`
+	Chunks    *ManifestChunkSpec `json:"chunks,omitempty"`
+	// Separator is the line separator specification (-t), if not a newline.
+	Separator string `json:"separator,omitempty"`
+}
+
+// ManifestChunkSpec is the parsed CHUNKS specification of -n.
+type ManifestChunkSpec struct {
+	Spec       string `json:"spec"`
+	Lines      bool   `json:"lines,omitempty"`
+	RoundRobin bool   `json:"round_robin,omitempty"`
+	K          int64  `json:"k,omitempty"`
+	N          int64  `json:"n"`
+	// LineBalanced tells that the l/N chunks have about the same number of
+	// lines instead of the same size.
+	LineBalanced bool `json:"line_balanced,omitempty"`
+}
+
+// ManifestSuffix describes how the chunks are named.
+type ManifestSuffix struct {
+	Prefix  string `json:"prefix"`
+	Length  int    `json:"length"`
+	Numeric bool   `json:"numeric,omitempty"`
+	Hex     bool   `json:"hex,omitempty"`
+	// Start is the suffix index of chunk 0.
+	Start int `json:"start,omitempty"`
+	// Auto tells that the suffix grows past Length like GNU split, from xyz
+	// to xzaaa.
+	Auto bool `json:"auto,omitempty"`
+	// Template names the chunks instead of the prefix and the suffix.
+	Template string `json:"template,omitempty"`
+	// Additional is appended after the suffix, before the Extension.
+	Additional string `json:"additional,omitempty"`
+	Extension  string `json:"extension,omitempty"`
+}
+
+// ManifestChunk describes one chunk. Offset, Length and the line range refer
+// to the input. The r/N chunks are interleaved, so they have no Offset nor
+// line range but the number of Lines. SHA256 is the checksum of the chunk as
+// stored, after compression, whose size is then CompressedSize.
+type ManifestChunk struct {
+	Index          int    `json:"index"`
+	Name           string `json:"name"`
+	Offset         *int64 `json:"offset,omitempty"`
+	Length         int64  `json:"length"`
+	FirstLine      *int64 `json:"first_line,omitempty"`
+	LastLine       *int64 `json:"last_line,omitempty"`
+	Lines          *int64 `json:"lines,omitempty"`
+	CompressedSize int64  `json:"compressed_size,omitempty"`
+	SHA256         string `json:"sha256"`
+}
+
+// ReadManifest reads a manifest written by Options.Manifest.
+func ReadManifest(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, fmt.Errorf(manifestReadErrorMsg, err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf(manifestReadErrorMsg, err)
+	}
+	return manifest, nil
+}
+
+// WriteManifest writes manifest as indented JSON to path.
+func WriteManifest(path string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf(manifestWriteErrorMsg, err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0666); err != nil {
+		return fmt.Errorf(manifestWriteErrorMsg, err)
+	}
+	return nil
+}
+
+// newManifest fills in everything but the input and the chunks from options.
+func newManifest(options Options) (Manifest, error) {
+	manifest := Manifest{}
+	if options.Bytes != "" {
+		separateByte, err := separateByteStrToInt(options.Bytes)
+		if err != nil {
+			return Manifest{}, err
+		}
+		manifest.Mode.Bytes = int64(separateByte)
+	} else if options.LineBytes != "" {
+		separateByte, err := separateByteStrToInt(options.LineBytes)
+		if err != nil {
+			return Manifest{}, err
+		}
+		manifest.Mode.LineBytes = int64(separateByte)
+	} else if options.Chunks != "" {
+		chunk, err := parseCHUNK(options.Chunks)
+		if err != nil {
+			return Manifest{}, err
+		}
+		manifest.Mode.Chunks = &ManifestChunkSpec{options.Chunks, chunk.L, chunk.R, chunk.K, chunk.N, chunk.L && options.LineBalanced}
+	} else if options.Lines != 0 {
+		manifest.Mode.Lines = options.Lines
+	} else {
+		manifest.Mode.Lines = defaultSeparateLineNumber
+	}
+	manifest.Mode.Separator = options.Separator
+
+	manifest.Suffix = suffixOf(options)
+	if manifest.Suffix.Prefix == "" {
+		manifest.Suffix.Prefix = "x"
+	}
+	if options.Compress != "" {
+		codec, err := LookupCodec(options.Compress)
+		if err != nil {
+			return Manifest{}, err
+		}
+		manifest.Suffix.Extension = codec.Extension
+	}
+	manifest.Input.Name = options.InputName
+	return manifest, nil
+}
+
+// manifestSplitter records every chunk of splitter and writes the manifest to path.
+type manifestSplitter struct {
+	splitter FileSplitter
+	path     string
+	manifest Manifest
+	// separator ends the lines counted in the chunks.
+	separator string
+	// decompress splits the decompressed content of compressed input.
+	decompress bool
+}
+
+func (s manifestSplitter) Split(reader io.Reader, sink ChunkSink) error {
+	manifest := s.manifest
+	if s.decompress {
+		decompressed, format, err := decompressReader(reader)
+		if err != nil {
+			return err
+		}
+		reader, manifest.Input.Decompressed = decompressed, format
+	}
+
+	input := &hashingReader{reader: reader, hash: sha256.New()}
+	reader = input
+	if seeker, ok := input.reader.(io.ReadSeeker); ok {
+		// Hash seekable input up front, so that it stays seekable for the splitter.
+		if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			if _, err := io.Copy(io.Discard, input); err != nil {
+				return fmt.Errorf(fileReadErrorMsg, err)
+			}
+			if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+				return fmt.Errorf(fileReadErrorMsg, err)
+			}
+			reader = seeker
+		}
+	}
+
+	manifestSink := NewManifestSink(sink)
+	manifestSink.separator = s.separator
+	var recorder ChunkSink = manifestSink
+	if compressor, ok := sink.(compressingSink); ok {
+		recorder = compressingManifestSink{manifestSink, compressor}
+	}
+	if err := s.splitter.Split(reader, recorder); err != nil {
+		return err
+	}
+	// Read what the splitter left, so that the checksum covers the whole input.
+	if reader == input {
+		if _, err := io.Copy(io.Discard, input); err != nil {
+			return fmt.Errorf(fileReadErrorMsg, err)
+		}
+	}
+
+	manifest.Input.Size = input.size
+	manifest.Input.SHA256 = hex.EncodeToString(input.hash.Sum(nil))
+	manifest.Chunks = manifestSink.Chunks(manifest.Mode.Chunks == nil || !manifest.Mode.Chunks.RoundRobin)
+	return WriteManifest(s.path, manifest)
+}
+
+type hashingReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	size   int64
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	r.size += int64(n)
+	return n, err
+}
+
+// ManifestSink passes every chunk to sink and records its name, length,
+// lines and checksum.
+type ManifestSink struct {
+	sink ChunkSink
+	// separator ends the lines. The zero value means a newline.
+	separator string
+	mu        sync.Mutex
+	chunks    []manifestChunkStats
+}
+
+// NewManifestSink returns a ManifestSink that records the chunks written to sink.
+func NewManifestSink(sink ChunkSink) *ManifestSink {
+	return &ManifestSink{sink: sink}
+}
+
+type manifestChunkStats struct {
+	index          int
+	name           string
+	length         int64
+	lines          *recordCounter
+	compressedSize int64
+	sum            []byte
+}
+
+func (sink *ManifestSink) Open(index int) (io.WriteCloser, error) {
+	outFile, err := sink.sink.Open(index)
+	if err != nil {
+		return nil, err
+	}
+	w := &manifestWriteCloser{outFile: outFile, sink: sink, hash: sha256.New(), stats: manifestChunkStats{index: index, lines: newRecordCounter(sink.separator)}}
+	if compressed, ok := outFile.(compressedChunk); ok {
+		return compressedManifestWriteCloser{w, compressed}, nil
+	}
+	return w, nil
+}
+
+func (sink *ManifestSink) checkChunks(count int64) error {
+	return checkChunkCount(sink.sink, count)
+}
+
+// Chunks returns the recorded chunks in index order. contiguous tells whether
+// the chunks follow each other in the input, so that their offsets and line
+// ranges can be computed.
+func (sink *ManifestSink) Chunks(contiguous bool) []ManifestChunk {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	stats := append([]manifestChunkStats(nil), sink.chunks...)
+	sort.Slice(stats, func(i, j int) bool { return stats[i].index < stats[j].index })
+
+	chunks := make([]ManifestChunk, 0, len(stats))
+	var offset, linesBefore int64
+	for _, stat := range stats {
+		chunk := ManifestChunk{
+			Index:          stat.index,
+			Name:           stat.name,
+			Length:         stat.length,
+			CompressedSize: stat.compressedSize,
+			SHA256:         hex.EncodeToString(stat.sum),
+		}
+		if contiguous {
+			// The last line of a chunk may go on in the next chunk or have no newline.
+			chunkOffset, firstLine, lastLine := offset, linesBefore+1, linesBefore+stat.lines.lines()
+			chunk.Offset, chunk.FirstLine, chunk.LastLine = &chunkOffset, &firstLine, &lastLine
+			offset += stat.length
+			linesBefore += stat.lines.count
+		} else {
+			lines := stat.lines.lines()
+			chunk.Lines = &lines
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+type manifestWriteCloser struct {
+	outFile io.WriteCloser
+	sink    *ManifestSink
+	hash    hash.Hash
+	stats   manifestChunkStats
+}
+
+func (w *manifestWriteCloser) Write(p []byte) (int, error) {
+	n, err := w.outFile.Write(p)
+	if n > 0 {
+		w.written(p[:n])
+	}
+	return n, err
+}
+
+func (w *manifestWriteCloser) written(p []byte) {
+	w.hash.Write(p)
+	w.stats.length += int64(len(p))
+	w.stats.lines.Write(p)
+}
+
+// Unwrap returns the file of the chunk, so that the byte splitters can copy
+// to it in the kernel and keep its holes.
+func (w *manifestWriteCloser) Unwrap() *os.File {
+	return unwrapFile(w.outFile)
+}
+
+func (w *manifestWriteCloser) record(p []byte) {
+	recordChunk(w.outFile, p)
+	w.written(p)
+}
+
+func (w *manifestWriteCloser) Close() error {
+	if err := w.outFile.Close(); err != nil {
+		return err
+	}
+	if named, ok := w.outFile.(interface{ Name() string }); ok {
+		w.stats.name = named.Name()
+	}
+	w.stats.sum = w.hash.Sum(nil)
+	if stored, ok := w.outFile.(storedDigester); ok {
+		w.stats.compressedSize, w.stats.sum = stored.storedDigest()
+	}
+
+	w.sink.mu.Lock()
+	defer w.sink.mu.Unlock()
+	w.sink.chunks = append(w.sink.chunks, w.stats)
+	return nil
+}
+
+// compressingManifestSink is the ManifestSink of a compressingSink, so that the
+// byte splitters can still apply their size limit after compression.
+type compressingManifestSink struct {
+	*ManifestSink
+	compressor compressingSink
+}
+
+func (sink compressingManifestSink) Overhead() int64 {
+	return sink.compressor.Overhead()
+}
+
+func (sink compressingManifestSink) CompressedSize(reader io.Reader) (int64, error) {
+	return sink.compressor.CompressedSize(reader)
+}
+
+// compressedManifestWriteCloser records a compressed chunk, which the byte
+// splitters flush block by block.
+type compressedManifestWriteCloser struct {
+	*manifestWriteCloser
+	chunk compressedChunk
+}
+
+func (w compressedManifestWriteCloser) Flush() error {
+	return w.chunk.Flush()
+}
+
+func (w compressedManifestWriteCloser) CompressedSize() int64 {
+	return w.chunk.CompressedSize()
+}
+
+// storedDigester is implemented by chunk writers that store other bytes than
+// the ones written to them, such as compressed ones.
+type storedDigester interface {
+	storedDigest() (int64, []byte)
+}
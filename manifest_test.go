@@ -0,0 +1,210 @@
+package split
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func splitWithManifest(t *testing.T, options Options, reader io.Reader) Manifest {
+	t.Helper()
+	options.Manifest = filepath.Join(t.TempDir(), "manifest.json")
+	splitter, err := NewSplitter(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewChunkSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := splitter.Split(reader, sink); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := ReadManifest(options.Manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func TestManifestLineSplitter(t *testing.T) {
+
+	defer deleteOutputFiles()
+	var lines strings.Builder
+	for i := 1; i <= 2007; i++ {
+		fmt.Fprintln(&lines, "line", i)
+	}
+	data := lines.String()
+
+	for kind, reader := range map[string]io.Reader{
+		"seekable": strings.NewReader(data),
+		"stream":   io.MultiReader(strings.NewReader(data)),
+	} {
+		manifest := splitWithManifest(t, Options{Lines: 1000, Prefix: "output", InputName: "lines.txt"}, reader)
+
+		if manifest.Input.Name != "lines.txt" || manifest.Input.Size != int64(len(data)) || manifest.Input.SHA256 != sha256Hex([]byte(data)) {
+			t.Errorf("%s: Incorrect input in the manifest: %+v", kind, manifest.Input)
+		}
+		if manifest.Mode.Lines != 1000 || manifest.Mode.Bytes != 0 || manifest.Mode.Chunks != nil {
+			t.Errorf("%s: Incorrect mode in the manifest: %+v", kind, manifest.Mode)
+		}
+		if manifest.Suffix != (ManifestSuffix{Prefix: "output", Length: 2, Auto: true}) {
+			t.Errorf("%s: Incorrect suffix in the manifest: %+v", kind, manifest.Suffix)
+		}
+		if len(manifest.Chunks) != 3 {
+			t.Fatalf("%s: Expected 3 chunks in the manifest, got %d", kind, len(manifest.Chunks))
+		}
+
+		var offset int64
+		for i, lineRange := range [][2]int64{{1, 1000}, {1001, 2000}, {2001, 2007}} {
+			chunk := manifest.Chunks[i]
+			content, err := os.ReadFile(chunk.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedName, _ := AlphabetFileNameCreater{digit: 2, prefix: "output"}.Create(i)
+			if chunk.Index != i || chunk.Name != expectedName {
+				t.Errorf("%s: Chunk %d has index %d and name %s", kind, i, chunk.Index, chunk.Name)
+			}
+			if *chunk.Offset != offset || chunk.Length != int64(len(content)) || chunk.SHA256 != sha256Hex(content) {
+				t.Errorf("%s: Chunk %d has incorrect offset, length or checksum: %+v", kind, i, chunk)
+			}
+			if *chunk.FirstLine != lineRange[0] || *chunk.LastLine != lineRange[1] || chunk.Lines != nil {
+				t.Errorf("%s: Chunk %d has lines %d-%d, expected %d-%d", kind, i, *chunk.FirstLine, *chunk.LastLine, lineRange[0], lineRange[1])
+			}
+			offset += chunk.Length
+		}
+	}
+}
+
+func TestManifestByteSplitterLineRanges(t *testing.T) {
+	// 4 lines of 10 bytes, the last one without a newline, split every 15 bytes.
+	data := "123456789\n123456789\n123456789\n123456789"
+	memory := &MemorySink{}
+	manifestSink := NewManifestSink(memory)
+	if err := (ByteSplitter{separateByteStr: "15"}).Split(strings.NewReader(data), manifestSink); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks := manifestSink.Chunks(true)
+	expected := [][3]int64{{0, 1, 2}, {15, 2, 3}, {30, 4, 4}}
+	if len(chunks) != len(expected) {
+		t.Fatalf("Expected %d chunks, got %d", len(expected), len(chunks))
+	}
+	for i, chunk := range chunks {
+		if *chunk.Offset != expected[i][0] || *chunk.FirstLine != expected[i][1] || *chunk.LastLine != expected[i][2] {
+			t.Errorf("Chunk %d: Expected offset %d, lines %d-%d, Got: %d, %d-%d", i, expected[i][0], expected[i][1], expected[i][2], *chunk.Offset, *chunk.FirstLine, *chunk.LastLine)
+		}
+		if chunk.Name != "" {
+			t.Errorf("Chunk %d: A memory chunk has no name, Got: %s", i, chunk.Name)
+		}
+	}
+}
+
+func TestManifestRoundRobin(t *testing.T) {
+
+	defer deleteOutputFiles()
+	var lines strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintln(&lines, "line", i)
+	}
+
+	manifest := splitWithManifest(t, Options{Chunks: "r/3", Prefix: "output", NumericSuffix: true}, strings.NewReader(lines.String()))
+
+	if *manifest.Mode.Chunks != (ManifestChunkSpec{Spec: "r/3", RoundRobin: true, N: 3}) {
+		t.Errorf("Incorrect mode in the manifest: %+v", *manifest.Mode.Chunks)
+	}
+	for i, expectedLines := range []int64{4, 3, 3} {
+		chunk := manifest.Chunks[i]
+		if chunk.Offset != nil || chunk.FirstLine != nil || chunk.LastLine != nil {
+			t.Errorf("Interleaved chunk %d has an offset or a line range: %+v", i, chunk)
+		}
+		if chunk.Lines == nil || *chunk.Lines != expectedLines {
+			t.Errorf("Chunk %d: Expected %d lines, Got: %v", i, expectedLines, chunk.Lines)
+		}
+		if chunk.Name != fmt.Sprintf("output%02d", i) {
+			t.Errorf("Chunk %d: Incorrect name %s", i, chunk.Name)
+		}
+	}
+}
+
+func TestManifestSeparatorLineRanges(t *testing.T) {
+
+	defer deleteOutputFiles()
+	input := "a\n---\nb\nc\n---\nd\n---\ne"
+	manifest := splitWithManifest(t, Options{Lines: 2, Separator: `\n---\n`, Prefix: "output"}, strings.NewReader(input))
+
+	if manifest.Mode.Separator != `\n---\n` {
+		t.Errorf("Incorrect separator in the manifest: %q", manifest.Mode.Separator)
+	}
+	for i, expected := range [][2]int64{{1, 2}, {3, 4}} {
+		chunk := manifest.Chunks[i]
+		if *chunk.FirstLine != expected[0] || *chunk.LastLine != expected[1] {
+			t.Errorf("Chunk %d: Expected lines %d-%d, Got: %d-%d", i, expected[0], expected[1], *chunk.FirstLine, *chunk.LastLine)
+		}
+	}
+}
+
+func TestManifestCompressedChunks(t *testing.T) {
+
+	defer deleteOutputFiles()
+	data := randomBytes(3000)
+	manifest := splitWithManifest(t, Options{Bytes: "1k", Compress: "gzip", Prefix: "output"}, strings.NewReader(string(data)))
+
+	if manifest.Suffix.Extension != ".gz" {
+		t.Errorf("Expected extension .gz, Got: %s", manifest.Suffix.Extension)
+	}
+	for i, chunk := range manifest.Chunks {
+		stored, err := os.ReadFile(chunk.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(chunk.Name, ".gz") || chunk.CompressedSize != int64(len(stored)) || chunk.SHA256 != sha256Hex(stored) {
+			t.Errorf("Chunk %d does not describe the stored file: %+v", i, chunk)
+		}
+		if chunk.Length != int64(len(gunzip(t, stored))) {
+			t.Errorf("Chunk %d: Expected length %d, Got: %d", i, len(gunzip(t, stored)), chunk.Length)
+		}
+	}
+}
+
+func TestManifestLimitAfterCompression(t *testing.T) {
+	data := append(bytes.Repeat([]byte("0123456789"), 5000), randomBytes(50000)...)
+	for _, options := range []Options{{Bytes: "20K"}, {Chunks: "3"}} {
+		dir := t.TempDir()
+		options.Compress, options.LimitAfterCompression, options.Prefix = "gzip", true, filepath.Join(dir, "output")
+		manifest := splitWithManifest(t, options, bytes.NewReader(data))
+
+		var output []byte
+		for i, chunk := range manifest.Chunks {
+			stored, err := os.ReadFile(chunk.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if options.Bytes != "" && len(stored) > 20*1024 {
+				t.Errorf("Options: %+v, Chunk %d is larger than the limit after compression: %d", options, i, len(stored))
+			}
+			if chunk.CompressedSize != int64(len(stored)) || chunk.SHA256 != sha256Hex(stored) || chunk.Length != int64(len(gunzip(t, stored))) {
+				t.Errorf("Options: %+v, Chunk %d does not describe the stored file: %+v", options, i, chunk)
+			}
+			output = append(output, gunzip(t, stored)...)
+		}
+		if !bytes.Equal(output, data) {
+			t.Errorf("Options: %+v, Incorrect chunk content.", options)
+		}
+		if options.Chunks != "" && len(manifest.Chunks) != 3 {
+			t.Errorf("Options: %+v, Expected 3 chunks, Got: %d", options, len(manifest.Chunks))
+		}
+	}
+}
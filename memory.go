@@ -0,0 +1,86 @@
+package split
+
+import (
+	"fmt"
+	"io"
+)
+
+// minBufferSize is the smallest read or copy buffer a splitter works with
+// under a memory limit. Lines longer than the buffer are still copied whole.
+const minBufferSize = 4 * 1024
+
+// memoryBudget sizes the buffers of a splitter so that together they fit in
+// limit bytes. The zero value has no limit and gives the default sizes.
+type memoryBudget struct {
+	limit int64
+}
+
+// newMemoryBudget returns the budget of a limit such as "64M". An empty limit
+// means no limit.
+func newMemoryBudget(maxMemoryStr string) (memoryBudget, error) {
+	if maxMemoryStr == "" {
+		return memoryBudget{}, nil
+	}
+	limit, err := separateByteStrToInt(maxMemoryStr)
+	if err != nil || limit <= 0 {
+		return memoryBudget{}, fmt.Errorf(maxMemoryInvalidErrorMsg)
+	}
+	return memoryBudget{int64(limit)}, nil
+}
+
+// lineBuffers returns the size of the read buffer of a line splitter and of
+// the write buffer of each of the outputs chunks it keeps open at once. A
+// write buffer of 0 leaves the chunks unbuffered.
+func (budget memoryBudget) lineBuffers(outputs int64) (int, int, error) {
+	read, write := int64(bufferSize), int64(outputBufferSize)
+	if outputs > 1 {
+		write = min(write, roundRobinBufferBudget/outputs)
+	}
+	if budget.limit > 0 && read+outputs*write > budget.limit {
+		// The write buffers shrink to at most half of the limit, and the read
+		// buffer takes the rest.
+		write = min(write, budget.limit/2/outputs)
+		if write < minOutputBufferSize {
+			write = 0
+		}
+		read = min(read, budget.limit-outputs*write)
+	}
+	if write < minOutputBufferSize {
+		write = 0
+	}
+	if read < minBufferSize {
+		return 0, 0, fmt.Errorf(maxMemoryLimitExceededErrorMsg)
+	}
+	return int(read), int(write), nil
+}
+
+// copyBuffer returns the size of the buffer of each of workers copying chunks
+// of size bytes.
+func (budget memoryBudget) copyBuffer(workers int, size int64) (int, error) {
+	buffer := min(max(size, 1), bufferSize)
+	if budget.limit > 0 && int64(workers)*buffer > budget.limit {
+		buffer = budget.limit / int64(workers)
+		if buffer < min(size, minBufferSize) {
+			return 0, fmt.Errorf(maxMemoryLimitExceededErrorMsg)
+		}
+	}
+	return int(buffer), nil
+}
+
+// copyRange copies size bytes of section from offset to writer through a
+// buffer that fits the budget.
+func (budget memoryBudget) copyRange(writer io.Writer, section *io.SectionReader, offset int64, size int64) error {
+	bufferLength, err := budget.copyBuffer(1, size)
+	if err != nil {
+		return err
+	}
+	return copyRangeBuffer(writer, section, offset, size, make([]byte, bufferLength))
+}
+
+// reserve checks that a buffer of size bytes, which cannot shrink, fits.
+func (budget memoryBudget) reserve(size int64) error {
+	if budget.limit > 0 && size > budget.limit {
+		return fmt.Errorf(maxMemoryLimitExceededErrorMsg)
+	}
+	return nil
+}
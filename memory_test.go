@@ -0,0 +1,158 @@
+package split
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewMemoryBudget(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected memoryBudget
+		err      string
+	}{
+		{"", memoryBudget{}, ""},
+		{"64K", memoryBudget{64 * 1024}, ""},
+		{"1MB", memoryBudget{1024 * 1024}, ""},
+		{"0", memoryBudget{}, maxMemoryInvalidErrorMsg},
+		{"10X", memoryBudget{}, maxMemoryInvalidErrorMsg},
+	}
+
+	for _, tc := range testCases {
+		got, err := newMemoryBudget(tc.input)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("Input: %s, Expected error: %s, Got: %v", tc.input, tc.err, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.expected {
+			t.Errorf("Input: %s, Expected: %+v, Got: %+v, %v", tc.input, tc.expected, got, err)
+		}
+	}
+}
+
+func TestMemoryBudgetLineBuffers(t *testing.T) {
+	testCases := []struct {
+		limit   int64
+		outputs int64
+		read    int
+		write   int
+		err     string
+	}{
+		{0, 1, bufferSize, outputBufferSize, ""},
+		{0, 1000, bufferSize, 16777, ""},
+		{0, 10000, bufferSize, 0, ""},
+		{2 * 1024 * 1024, 1, bufferSize, outputBufferSize, ""},
+		{256 * 1024, 1, 192 * 1024, outputBufferSize, ""},
+		{256 * 1024, 10, 131074, 13107, ""},
+		{256 * 1024, 100, 256 * 1024, 0, ""},
+		{6 * 1024, 1, 6 * 1024, 0, ""},
+		{2 * 1024, 1, 0, 0, maxMemoryLimitExceededErrorMsg},
+	}
+
+	for _, tc := range testCases {
+		read, write, err := memoryBudget{tc.limit}.lineBuffers(tc.outputs)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("Input: %d, %d, Expected error: %s, Got: %v", tc.limit, tc.outputs, tc.err, err)
+			}
+			continue
+		}
+		if err != nil || read != tc.read || write != tc.write {
+			t.Errorf("Input: %d, %d, Expected: %d, %d, Got: %d, %d, %v", tc.limit, tc.outputs, tc.read, tc.write, read, write, err)
+		}
+		if tc.limit > 0 && int64(read)+tc.outputs*int64(write) > tc.limit {
+			t.Errorf("Input: %d, %d, Expected buffers within the limit, Got: %d, %d", tc.limit, tc.outputs, read, write)
+		}
+	}
+}
+
+func TestMemoryBudgetCopyBuffer(t *testing.T) {
+	testCases := []struct {
+		limit   int64
+		workers int
+		size    int64
+		buffer  int
+		err     string
+	}{
+		{0, 1, 100, 100, ""},
+		{0, 8, 100 * 1024 * 1024, bufferSize, ""},
+		{64 * 1024, 8, 100 * 1024 * 1024, 8 * 1024, ""},
+		{64 * 1024, 4, 1000, 1000, ""},
+		{16 * 1024, 8, 100 * 1024 * 1024, 0, maxMemoryLimitExceededErrorMsg},
+		{1000, 1, 2000, 0, maxMemoryLimitExceededErrorMsg},
+		{1000, 1, 500, 500, ""},
+	}
+
+	for _, tc := range testCases {
+		buffer, err := memoryBudget{tc.limit}.copyBuffer(tc.workers, tc.size)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("Input: %d, %d, %d, Expected error: %s, Got: %v", tc.limit, tc.workers, tc.size, tc.err, err)
+			}
+			continue
+		}
+		if err != nil || buffer != tc.buffer {
+			t.Errorf("Input: %d, %d, %d, Expected: %d, Got: %d, %v", tc.limit, tc.workers, tc.size, tc.buffer, buffer, err)
+		}
+	}
+}
+
+func TestSplittersWithinMemoryLimit(t *testing.T) {
+	var lines strings.Builder
+	for i := 1; i <= 20000; i++ {
+		fmt.Fprintln(&lines, "line", i, strings.Repeat("x", i%700))
+	}
+	data := lines.String()
+
+	testCases := []struct {
+		name       string
+		options    Options
+		err        string
+		contiguous bool
+	}{
+		{"Lines", Options{Lines: 1000, MaxMemory: "16K"}, "", true},
+		{"Bytes", Options{Bytes: "1M", MaxMemory: "16K"}, "", true},
+		{"BytesParallel", Options{Bytes: "1M", Parallel: 4, MaxMemory: "16K"}, "", true},
+		{"LineBytes", Options{LineBytes: "100K", MaxMemory: "16K"}, "", true},
+		{"Pieces", Options{Chunks: "5", MaxMemory: "16K"}, "", true},
+		{"PieceLines", Options{Chunks: "l/5", MaxMemory: "16K"}, "", true},
+		{"PieceLinesBalanced", Options{Chunks: "l/5", LineBalanced: true, MaxMemory: "16K"}, "", true},
+		{"RoundRobin", Options{Chunks: "r/500", MaxMemory: "16K"}, "", false},
+		{"LinesTooSmall", Options{Lines: 1000, MaxMemory: "2K"}, maxMemoryLimitExceededErrorMsg, true},
+		{"BytesParallelTooSmall", Options{Bytes: "1M", Parallel: 8, MaxMemory: "16K"}, maxMemoryLimitExceededErrorMsg, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			splitter, err := newModeSplitter(tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sink := &MemorySink{}
+			err = splitter.Split(strings.NewReader(data), sink)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatal("Incorrect error. Expected ", tc.err, ", got ", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var output bytes.Buffer
+			for _, chunk := range sink.Chunks {
+				output.Write(chunk.Bytes())
+			}
+			if output.Len() != len(data) {
+				t.Fatal("Incorrect total chunk size. Expected ", len(data), ", got ", output.Len())
+			}
+			if tc.contiguous && output.String() != data {
+				t.Fatal("Incorrect chunk content.")
+			}
+		})
+	}
+}
@@ -0,0 +1,446 @@
+package split
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultSeparateLineNumber is the number of lines per chunk when no mode is selected.
+const defaultSeparateLineNumber = 1000
+
+// defaultSuffixLength is the suffix length when SuffixLength is 0.
+const defaultSuffixLength = 2
+
+// Options selects how the input is split and how the chunks are named.
+// At most one of Lines, Bytes, LineBytes and Chunks can be set. When none of
+// them is set, the input is split every 1000 lines.
+type Options struct {
+	// Lines is the number of lines per chunk (-l).
+	Lines int64
+	// Bytes is the size of each chunk, such as "100K" (-b).
+	Bytes string
+	// LineBytes is the maximum size of each chunk, such as "100K", filled
+	// with whole lines (-C). Only a longer line on its own is broken.
+	LineBytes string
+	// Chunks is the CHUNKS specification, such as "3", "l/3" or "r/2/3" (-n).
+	Chunks string
+	// LineBalanced makes the l/N chunks of about the same number of lines
+	// instead of about the same size.
+	LineBalanced bool
+	// Separator ends the lines of Lines, LineBytes and the l/ and r/ Chunks
+	// instead of a newline (-t). It can be several bytes long, with the escapes \0, \t,
+	// \n, \r and \\.
+	Separator string
+
+	// Parallel is the number of chunks of Bytes and Chunks N copied at once,
+	// each at its own offset. 0 and 1 copy them one after another. Bytes
+	// needs a seekable input for it, such as a file. The chunks are the same
+	// either way, but the sink must be safe for concurrent use.
+	Parallel int
+	// MaxMemory caps the buffers the splitters allocate together, such as
+	// "64M". The buffers shrink to fit, and splitting fails with "memory
+	// limit exceeded" when they cannot. Empty means no limit. Chunks kept in
+	// memory by the sink and compression codecs are not counted.
+	MaxMemory string
+
+	// Decompress detects gzip, bzip2 and zlib input from its magic bytes and
+	// splits the decompressed content instead. It is off by default, so that
+	// the chunks of a compressed file join back into the same file.
+	Decompress bool
+
+	// SuffixLength is the number of suffix characters (-a). 0 means 2,
+	// grown like GNU split once the names run out, or as long as the N of
+	// Chunks needs.
+	SuffixLength int
+	// NumericSuffix uses digits instead of letters for the suffix (-d).
+	NumericSuffix bool
+	// HexSuffix uses hexadecimal digits for the suffix (-x). It wins over
+	// NumericSuffix.
+	HexSuffix bool
+	// SuffixStart is the suffix index of the first chunk, so that the names
+	// go on from an earlier split (--numeric-suffixes=FROM). Like GNU split,
+	// the suffix then does not grow without SuffixLength.
+	SuffixStart int
+	// Prefix is the file name prefix. An empty prefix means "x".
+	Prefix string
+	// AdditionalSuffix is appended to the names after the suffix, such as
+	// ".csv", and before the extension of Compress. It cannot hold a slash.
+	AdditionalSuffix string
+	// OutputDir is the directory the chunks are created in, under the
+	// directory of Prefix. NewChunkSink creates it when it is missing.
+	OutputDir string
+	// NameTemplate names the chunks instead of Prefix and the suffix, such as
+	// "{name}.part{index:04}-of-{total:04}{ext}", see TemplateFileNameCreater.
+	// {index} starts at SuffixStart.
+	NameTemplate string
+	// ContentNames names every chunk by the hex SHA-256 of its content as
+	// stored, after Prefix, so that identical chunks share a file. The chunks
+	// are renamed once written. Without Manifest, the index, the SHA-256 and
+	// the name of every chunk are listed on Stdout.
+	ContentNames bool
+	// ContentNamesIndex is ContentNames with the index before the SHA-256,
+	// as SuffixLength decimal digits and a dash, such as "x03-3b1f…". The
+	// index grows past SuffixLength like the suffixes without it, 89 then
+	// 9000, so that the names sort in chunk order.
+	ContentNamesIndex bool
+
+	// Filter is a shell command that receives every chunk on its stdin
+	// instead of a file being created, with $FILE set to the chunk name.
+	Filter string
+
+	// Compress is the name of a registered codec, such as "gzip" or "zlib",
+	// that compresses every chunk. Its extension is appended to the names.
+	Compress string
+	// LimitAfterCompression applies the size of Bytes, or the equal share of
+	// Chunks N and K/N, to the compressed chunks instead of the input.
+	LimitAfterCompression bool
+
+	// Manifest is the path of a JSON manifest describing the input and every
+	// chunk. No manifest is written when it is empty.
+	Manifest string
+	// InputName is the input name recorded in the manifest.
+	InputName string
+
+	// Stdout receives chunk K of the K/N specifications and the output of
+	// Filter. nil means os.Stdout.
+	Stdout io.Writer
+}
+
+// NewSplitter returns the FileSplitter selected by options.
+func NewSplitter(options Options) (FileSplitter, error) {
+	splitter, err := newModeSplitter(options)
+	if err != nil {
+		return nil, err
+	}
+	if options.Manifest != "" {
+		manifest, err := newManifest(options)
+		if err != nil {
+			return nil, err
+		}
+		separator, err := parseSeparator(options.Separator)
+		if err != nil {
+			return nil, err
+		}
+		// The manifest splitter decompresses the input itself, to record
+		// the format it is decompressed from.
+		return manifestSplitter{splitter, options.Manifest, manifest, separator, options.Decompress}, nil
+	}
+	if options.Decompress {
+		return decompressSplitter{splitter}, nil
+	}
+	return splitter, nil
+}
+
+// newModeSplitter returns the FileSplitter of the mode selected by options.
+func newModeSplitter(options Options) (FileSplitter, error) {
+	modes := 0
+	if options.Lines != 0 {
+		modes++
+	}
+	if options.Bytes != "" {
+		modes++
+	}
+	if options.LineBytes != "" {
+		modes++
+	}
+	if options.Chunks != "" {
+		modes++
+	}
+	if modes > 1 {
+		return nil, fmt.Errorf(tooManyModeErrorMsg)
+	}
+	if options.Parallel < 0 {
+		return nil, fmt.Errorf(parallelInvalidErrorMsg)
+	}
+	if options.LimitAfterCompression && (options.Compress == "" || (options.Bytes == "" && options.Chunks == "")) {
+		return nil, fmt.Errorf(compressLimitModeErrorMsg)
+	}
+
+	separator, err := parseSeparator(options.Separator)
+	if err != nil {
+		return nil, err
+	}
+	budget, err := newMemoryBudget(options.MaxMemory)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Bytes != "" {
+		splitter, err := NewByteSplitter(options.Bytes)
+		splitter.afterCompression = options.LimitAfterCompression
+		splitter.parallel = options.Parallel
+		splitter.budget = budget
+		return splitter, err
+	}
+	if options.LineBytes != "" {
+		splitter, err := NewLineByteSplitter(options.LineBytes)
+		splitter.separator = separator
+		splitter.budget = budget
+		return splitter, err
+	}
+	if options.Chunks != "" {
+		splitter, err := NewPieceSplitter(options.Chunks, options.Stdout)
+		splitter.afterCompression = options.LimitAfterCompression
+		splitter.separator = separator
+		splitter.lineBalanced = options.LineBalanced
+		splitter.parallel = options.Parallel
+		splitter.budget = budget
+		return splitter, err
+	}
+	lines := options.Lines
+	if lines == 0 {
+		lines = defaultSeparateLineNumber
+	}
+	splitter, err := NewLineSplitter(lines)
+	splitter.separator = separator
+	splitter.budget = budget
+	return splitter, err
+}
+
+// NewFileNameCreater returns the FileNameCreater of the prefix and suffix
+// selected by options. NewChunkSink names the chunks by NameTemplate instead
+// when it is set.
+func NewFileNameCreater(options Options) FileNameCreater {
+	return newFileNameCreater(suffixOf(options))
+}
+
+// suffixOf returns the suffix scheme selected by options.
+func suffixOf(options Options) ManifestSuffix {
+	suffix := ManifestSuffix{Prefix: options.Prefix, Numeric: options.NumericSuffix && !options.HexSuffix, Hex: options.HexSuffix, Start: options.SuffixStart, Template: options.NameTemplate, Additional: options.AdditionalSuffix}
+	suffix.Length, suffix.Auto = suffixLengthOf(options)
+	if options.ContentNames || options.ContentNamesIndex {
+		suffix.Template = contentTemplate(options.Prefix, suffix.Length, options.ContentNamesIndex)
+	}
+	if options.OutputDir != "" {
+		prefix := options.Prefix
+		if prefix == "" {
+			prefix = "x"
+		}
+		// A prefix ending with a slash names the chunks in a directory, so it
+		// is not cleaned by filepath.Join.
+		suffix.Prefix = filepath.Join(options.OutputDir, ".") + string(filepath.Separator) + prefix
+		if suffix.Template != "" {
+			dir := strings.NewReplacer("{", "{{", "}", "}}").Replace(filepath.Join(options.OutputDir, "."))
+			suffix.Template = dir + string(filepath.Separator) + suffix.Template
+		}
+	}
+	return suffix
+}
+
+// suffixLengthOf returns the suffix length of options, and whether the suffix
+// grows once its names run out. Without SuffixLength, the suffix of Chunks
+// is long enough for the N chunks, and the other suffixes grow like GNU split.
+func suffixLengthOf(options Options) (int, bool) {
+	if options.SuffixLength != 0 {
+		return options.SuffixLength, false
+	}
+	base := 26
+	if options.HexSuffix {
+		base = 16
+	} else if options.NumericSuffix {
+		base = 10
+	}
+	if chunk, err := parseCHUNK(options.Chunks); options.Chunks != "" && err == nil {
+		return max(defaultSuffixLength, suffixLengthFor(int64(options.SuffixStart)+chunk.N, base)), false
+	}
+	if options.SuffixStart != 0 {
+		// Like GNU split, the first name is FROM as it is, and the suffix
+		// is as long as FROM and does not grow.
+		return max(defaultSuffixLength, suffixLengthFor(int64(options.SuffixStart)+1, base)), false
+	}
+	return defaultSuffixLength, true
+}
+
+// newFileNameCreater returns the FileNameCreater of a suffix scheme.
+func newFileNameCreater(suffix ManifestSuffix) FileNameCreater {
+	if suffix.Additional != "" {
+		additional := suffix.Additional
+		suffix.Additional = ""
+		return NewExtensionFileNameCreater(newFileNameCreater(suffix), additional)
+	}
+	if suffix.Hex {
+		fileNameCreater := NewHexFileNameCreater(suffix.Length, suffix.Prefix)
+		fileNameCreater.start, fileNameCreater.auto = suffix.Start, suffix.Auto
+		return fileNameCreater
+	}
+	if suffix.Numeric {
+		fileNameCreater := NewNumericFileNameCreater(suffix.Length, suffix.Prefix)
+		fileNameCreater.start, fileNameCreater.auto = suffix.Start, suffix.Auto
+		return fileNameCreater
+	}
+	fileNameCreater := NewAlphabetFileNameCreater(suffix.Length, suffix.Prefix)
+	fileNameCreater.start, fileNameCreater.auto = suffix.Start, suffix.Auto
+	return fileNameCreater
+}
+
+// NewChunkSink returns the ChunkSink selected by options.
+func NewChunkSink(options Options) (ChunkSink, error) {
+	if strings.ContainsRune(options.AdditionalSuffix, '/') || strings.ContainsRune(options.AdditionalSuffix, filepath.Separator) {
+		return nil, fmt.Errorf(additionalSuffixInvalidErrorMsg, options.AdditionalSuffix)
+	}
+	var fileNameCreater FileNameCreater = NewFileNameCreater(options)
+	var template *TemplateFileNameCreater
+	if options.NameTemplate != "" && (options.ContentNames || options.ContentNamesIndex) {
+		return nil, fmt.Errorf(contentNamesTemplateErrorMsg)
+	}
+	if suffixOf(options).Template != "" {
+		var err error
+		if template, err = newTemplateOf(options); err != nil {
+			return nil, err
+		}
+		fileNameCreater = template
+		if options.AdditionalSuffix != "" {
+			fileNameCreater = NewExtensionFileNameCreater(fileNameCreater, options.AdditionalSuffix)
+		}
+	}
+	if options.Filter == "" {
+		if err := prepareOutputDir(options); err != nil {
+			return nil, err
+		}
+	}
+	var codec Codec
+	if options.Compress != "" {
+		var err error
+		if codec, err = LookupCodec(options.Compress); err != nil {
+			return nil, err
+		}
+		fileNameCreater = NewExtensionFileNameCreater(fileNameCreater, codec.Extension)
+	}
+
+	var sink ChunkSink
+	if options.Filter != "" {
+		sink = NewFilterSink(options.Filter, fileNameCreater, options.Stdout)
+	} else {
+		sink = NewFileSink(fileNameCreater)
+	}
+	if options.Compress != "" {
+		sink = NewCompressSink(sink, codec)
+	}
+	if template != nil && template.deferred() {
+		separator, err := parseSeparator(options.Separator)
+		if err != nil {
+			return nil, err
+		}
+		renamer := &templateSink{sink: sink, template: template, extension: options.AdditionalSuffix + codec.Extension, separator: separator}
+		template.extension = renamer.extension
+		if (options.ContentNames || options.ContentNamesIndex) && options.Manifest == "" {
+			renamer.listing = options.Stdout
+			if renamer.listing == nil {
+				renamer.listing = os.Stdout
+			}
+		}
+		sink = renamer
+	}
+	return sink, nil
+}
+
+// contentTemplate returns the name template of ContentNames after prefix, "x"
+// when empty. The index is padded to length digits and grows past them when
+// index is set.
+func contentTemplate(prefix string, length int, index bool) string {
+	if prefix == "" {
+		prefix = "x"
+	}
+	template := strings.NewReplacer("{", "{{", "}", "}}").Replace(prefix)
+	if index {
+		template += fmt.Sprintf("{%s:%dg}-", templateIndex, length)
+	}
+	return template + "{" + templateSHA256 + "}"
+}
+
+// newTemplateOf returns the TemplateFileNameCreater of options. The chunks
+// renamed once written must be written to files, and for their offsets and
+// lines, one after another.
+func newTemplateOf(options Options) (*TemplateFileNameCreater, error) {
+	template, err := NewTemplateFileNameCreater(suffixOf(options).Template, options.InputName)
+	if err != nil {
+		return nil, err
+	}
+	template.start = options.SuffixStart
+	if !template.deferred() {
+		return template, nil
+	}
+	if template.uses(templateOffset, templateFirstLine, templateLastLine) {
+		if chunk, err := parseCHUNK(options.Chunks); options.Chunks != "" && err == nil && chunk.R {
+			return nil, fmt.Errorf(templateOrderOptionErrorMsg, "round robin Chunks")
+		}
+		if options.Parallel > 1 {
+			return nil, fmt.Errorf(templateOrderOptionErrorMsg, "Parallel")
+		}
+	}
+	if options.Filter != "" {
+		return nil, fmt.Errorf(templateDeferredOptionErrorMsg, "Filter")
+	}
+	if options.LimitAfterCompression {
+		return nil, fmt.Errorf(templateDeferredOptionErrorMsg, "LimitAfterCompression")
+	}
+	return template, nil
+}
+
+// NewLineSplitter returns a LineSplitter that puts separateLineNumber lines in each chunk.
+func NewLineSplitter(separateLineNumber int64) (LineSplitter, error) {
+	if separateLineNumber <= 0 {
+		return LineSplitter{}, fmt.Errorf(separateLineInvalidErrorMsg)
+	}
+	return LineSplitter{separateLineNumber: separateLineNumber}, nil
+}
+
+// NewByteSplitter returns a ByteSplitter for a size such as "100", "100K" or "1MB".
+func NewByteSplitter(separateByteStr string) (ByteSplitter, error) {
+	separateByte, err := separateByteStrToInt(separateByteStr)
+	if err != nil {
+		return ByteSplitter{}, err
+	}
+	if separateByte <= 0 {
+		return ByteSplitter{}, fmt.Errorf(separateByteInvalidErrorMsg)
+	}
+	return ByteSplitter{separateByteStr: separateByteStr}, nil
+}
+
+// NewLineByteSplitter returns a LineByteSplitter for a size such as "100",
+// "100K" or "1MB".
+func NewLineByteSplitter(separateByteStr string) (LineByteSplitter, error) {
+	separateByte, err := separateByteStrToInt(separateByteStr)
+	if err != nil {
+		return LineByteSplitter{}, err
+	}
+	if separateByte <= 0 {
+		return LineByteSplitter{}, fmt.Errorf(separateByteInvalidErrorMsg)
+	}
+	return LineByteSplitter{separateByteStr: separateByteStr}, nil
+}
+
+// NewPieceSplitter returns a PieceSplitter for a CHUNKS specification.
+// Chunk K of the K/N specifications is written to stdout, or os.Stdout when nil.
+func NewPieceSplitter(chunkStr string, stdout io.Writer) (PieceSplitter, error) {
+	if _, err := parseCHUNK(chunkStr); err != nil {
+		return PieceSplitter{}, err
+	}
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	return PieceSplitter{chunkStr: chunkStr, writer: stdout}, nil
+}
+
+// NewAlphabetFileNameCreater returns a FileNameCreater with digit letters after prefix.
+func NewAlphabetFileNameCreater(digit int, prefix string) AlphabetFileNameCreater {
+	return AlphabetFileNameCreater{digit: digit, prefix: prefix}
+}
+
+// NewNumericFileNameCreater returns a FileNameCreater with digit numbers after prefix.
+func NewNumericFileNameCreater(digit int, prefix string) NumericFileNameCreater {
+	return NumericFileNameCreater{digit: digit, prefix: prefix}
+}
+
+// NewHexFileNameCreater returns a FileNameCreater with hexadecimal suffixes of digit characters.
+func NewHexFileNameCreater(digit int, prefix string) HexFileNameCreater {
+	return HexFileNameCreater{digit: digit, prefix: prefix}
+}
+
+// NewFileSink returns a ChunkSink that writes every chunk to the file named by fileNameCreater.
+func NewFileSink(fileNameCreater FileNameCreater) FileSink {
+	return FileSink{fileNameCreater}
+}
@@ -0,0 +1,111 @@
+package split
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewSplitter(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	testCases := []struct {
+		options  Options
+		expected FileSplitter
+		err      string
+	}{
+		{Options{}, LineSplitter{separateLineNumber: 1000}, ""},
+		{Options{Lines: 100}, LineSplitter{separateLineNumber: 100}, ""},
+		{Options{Bytes: "100K"}, ByteSplitter{separateByteStr: "100K"}, ""},
+		{Options{LineBytes: "1K", Separator: `\0`}, LineByteSplitter{separateByteStr: "1K", separator: "\x00"}, ""},
+		{Options{Chunks: "l/3", Stdout: stdout}, PieceSplitter{chunkStr: "l/3", writer: stdout}, ""},
+		{Options{Lines: -1}, nil, separateLineInvalidErrorMsg},
+		{Options{Bytes: "0"}, nil, separateByteInvalidErrorMsg},
+		{Options{Bytes: "10X"}, nil, separateByteInvalidErrorMsg},
+		{Options{Chunks: "x/3"}, nil, chunkFormatInvalidErrorMsg},
+		{Options{Lines: 100, Bytes: "100K"}, nil, tooManyModeErrorMsg},
+		{Options{Lines: 100, Chunks: "3"}, nil, tooManyModeErrorMsg},
+		{Options{Bytes: "100K", Chunks: "3"}, nil, tooManyModeErrorMsg},
+		{Options{LineBytes: "0"}, nil, separateByteInvalidErrorMsg},
+		{Options{LineBytes: "1K", Lines: 100}, nil, tooManyModeErrorMsg},
+		{Options{Bytes: "1K", Parallel: 4}, ByteSplitter{separateByteStr: "1K", parallel: 4}, ""},
+		{Options{Chunks: "3", Parallel: 4, Stdout: stdout}, PieceSplitter{chunkStr: "3", writer: stdout, parallel: 4}, ""},
+		{Options{Bytes: "1K", Parallel: -1}, nil, parallelInvalidErrorMsg},
+		{Options{Lines: 10, MaxMemory: "1M"}, LineSplitter{separateLineNumber: 10, budget: memoryBudget{1024 * 1024}}, ""},
+		{Options{Lines: 10, MaxMemory: "1Q"}, nil, maxMemoryInvalidErrorMsg},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewSplitter(tc.options)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("Input: %+v, Expected error: %s, Got: %v", tc.options, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Input: %+v, Expected no error, Got: %v", tc.options, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("Input: %+v, Expected: %#v, Got: %#v", tc.options, tc.expected, got)
+		}
+	}
+}
+
+func TestNewPieceSplitterDefaultsToStdout(t *testing.T) {
+	splitter, err := NewPieceSplitter("1/3", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if splitter.writer == nil {
+		t.Fatal("PieceSplitter has no writer for chunk K.")
+	}
+}
+
+func TestNewFileNameCreater(t *testing.T) {
+	testCases := []struct {
+		options          Options
+		fileNumber       int
+		expectedFileName string
+	}{
+		{Options{}, 27, "xbb"},
+		{Options{Prefix: "output"}, 0, "outputaa"},
+		{Options{SuffixLength: 3}, 1, "xaab"},
+		{Options{NumericSuffix: true}, 7, "x07"},
+		{Options{NumericSuffix: true, SuffixLength: 4, Prefix: "part"}, 12, "part0012"},
+		{Options{}, 650, "xzaaa"},
+		{Options{NumericSuffix: true}, 90, "x9000"},
+		{Options{Chunks: "1000"}, 0, "xaaa"},
+		{Options{Chunks: "l/2/1000", NumericSuffix: true}, 999, "x999"},
+		{Options{Chunks: "3"}, 2, "xac"},
+		{Options{HexSuffix: true, SuffixLength: 2}, 255, "xff"},
+		{Options{HexSuffix: true}, 240, "xf000"},
+		{Options{HexSuffix: true, NumericSuffix: true}, 10, "x0a"},
+		{Options{NumericSuffix: true, SuffixStart: 5}, 0, "x05"},
+		{Options{Chunks: "3", NumericSuffix: true, SuffixStart: 99}, 0, "x099"},
+		{Options{SuffixStart: 650}, 0, "xza"},
+		// FROM names the first chunk as it is, and the suffix does not grow.
+		{Options{NumericSuffix: true, SuffixStart: 95}, 0, "x95"},
+		{Options{NumericSuffix: true, SuffixStart: 95}, 4, "x99"},
+		{Options{NumericSuffix: true, SuffixStart: 100}, 0, "x100"},
+		{Options{AdditionalSuffix: ".csv"}, 1, "xab.csv"},
+		{Options{OutputDir: "out"}, 0, "out/xaa"},
+		{Options{OutputDir: "out/", Prefix: "parts/p", AdditionalSuffix: ".txt"}, 0, "out/parts/paa.txt"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewFileNameCreater(tc.options).Create(tc.fileNumber)
+		if err != nil {
+			t.Errorf("Input: %+v, %d, Expected no error, Got: %v", tc.options, tc.fileNumber, err)
+		}
+		if got != tc.expectedFileName {
+			t.Errorf("Input: %+v, %d, Expected: %s, Got: %s", tc.options, tc.fileNumber, tc.expectedFileName, got)
+		}
+	}
+}
+
+func TestNewFileNameCreaterStartRunsOut(t *testing.T) {
+	_, err := NewFileNameCreater(Options{NumericSuffix: true, SuffixStart: 95}).Create(5)
+	if err == nil || err.Error() != tooBigFileNumberErrorMsg {
+		t.Errorf("Expected: %s, Got: %v", tooBigFileNumberErrorMsg, err)
+	}
+}
@@ -0,0 +1,182 @@
+package split
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// inputRange is the input of the byte splitters that can be read at any
+// offset. file is set when the input is a file, with the section starting at
+// its offset base, so that the chunks can be copied in the kernel.
+type inputRange struct {
+	*io.SectionReader
+	file *os.File
+	base int64
+}
+
+// rangeOf is sectionOf that also keeps the file under the section.
+func rangeOf(reader io.Reader) (inputRange, func(), error) {
+	if input, ok := seekableRange(reader); ok {
+		return input, func() {}, nil
+	}
+	section, cleanup, err := sectionOf(reader)
+	if err != nil {
+		return inputRange{}, nil, err
+	}
+	return inputRange{SectionReader: section}, cleanup, nil
+}
+
+// seekableRange returns the range of reader from its current offset when it
+// can be read at any offset.
+func seekableRange(reader io.Reader) (inputRange, bool) {
+	seeker, ok := reader.(interface {
+		io.ReaderAt
+		io.Seeker
+	})
+	if !ok {
+		return inputRange{}, false
+	}
+	base, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return inputRange{}, false
+	}
+	section, err := seekerSection(seeker)
+	if err != nil {
+		return inputRange{}, false
+	}
+	file, _ := reader.(*os.File)
+	return inputRange{section, file, base}, true
+}
+
+// copyChunks copies input to sink in chunks of size bytes, with up to
+// parallel chunks copied at once by ReadAt. Every chunk has a fixed index and
+// byte range, so the output does not depend on the order the workers run in.
+// When several chunks fail, the error of the first one is returned.
+func copyChunks(input inputRange, sink ChunkSink, size int64, parallel int, budget memoryBudget) error {
+	if input.Size() == 0 {
+		return nil
+	}
+	count := (input.Size() + size - 1) / size
+	workers := int(min(int64(max(parallel, 1)), count))
+	bufferLength, err := budget.copyBuffer(workers, size)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failed   atomic.Bool
+		errIndex int
+		firstErr error
+	)
+	indexes := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every worker reuses its buffer for all of its chunks.
+			buffer := make([]byte, bufferLength)
+			for index := range indexes {
+				offset := int64(index) * size
+				err := copyChunk(input, sink, index, offset, min(size, input.Size()-offset), buffer)
+				if err == nil {
+					continue
+				}
+				failed.Store(true)
+				mu.Lock()
+				if firstErr == nil || index < errIndex {
+					errIndex, firstErr = index, err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for index := 0; int64(index) < count && !failed.Load(); index++ {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+	return firstErr
+}
+
+// copyChunk copies size bytes of input from offset to the chunk index of sink.
+// A file is copied to a file in the kernel when it can be, keeping its holes.
+func copyChunk(input inputRange, sink ChunkSink, index int, offset int64, size int64, buffer []byte) error {
+	outFile, err := sink.Open(index)
+	if err != nil {
+		return err
+	}
+	var copied int64
+	if file := unwrapFile(outFile); file != nil && input.file != nil {
+		sparse, err := copySparse(file, input, offset, size, buffer)
+		if err != nil {
+			closeChunk(outFile)
+			return err
+		}
+		if sparse {
+			copied = size
+		} else {
+			copied = copyFileRange(file, input.file, input.base+offset, size)
+		}
+		if err := recordRange(outFile, input, offset, copied, buffer); err != nil {
+			closeChunk(outFile)
+			return err
+		}
+	}
+	if err := copyRangeBuffer(outFile, input.SectionReader, offset+copied, size-copied, buffer); err != nil {
+		closeChunk(outFile)
+		return err
+	}
+	return closeChunk(outFile)
+}
+
+// fileChunk is a chunk writer over a file that keeps a record of the bytes
+// written, such as the checksum of a manifest. The bytes copied to the file
+// underneath directly are passed to record instead of Write.
+type fileChunk interface {
+	Unwrap() *os.File
+	record(p []byte)
+}
+
+// unwrapFile returns the file under writer, or nil when it writes elsewhere.
+func unwrapFile(writer io.Writer) *os.File {
+	switch w := writer.(type) {
+	case *os.File:
+		return w
+	case fileChunk:
+		return w.Unwrap()
+	}
+	return nil
+}
+
+// recordChunk passes p, copied to the file under writer directly, to writer
+// when it keeps a record of its bytes.
+func recordChunk(writer io.Writer, p []byte) {
+	if chunk, ok := writer.(fileChunk); ok {
+		chunk.record(p)
+	}
+}
+
+// recordRange passes size bytes of input from offset, copied to the file
+// under outFile directly, to outFile when it keeps a record of its bytes.
+// They are read through buffer.
+func recordRange(outFile io.Writer, input inputRange, offset int64, size int64, buffer []byte) error {
+	chunk, ok := outFile.(fileChunk)
+	if !ok || size == 0 {
+		return nil
+	}
+	return copyRangeBuffer(chunkRecorder{chunk}, input.SectionReader, offset, size, buffer)
+}
+
+// chunkRecorder passes everything written to it to the record of chunk.
+type chunkRecorder struct {
+	chunk fileChunk
+}
+
+func (w chunkRecorder) Write(p []byte) (int, error) {
+	w.chunk.record(p)
+	return len(p), nil
+}
@@ -0,0 +1,153 @@
+package split
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyChunksMatchesSequential(t *testing.T) {
+	data := strings.Repeat("0123456789abcdefghijklmnopqrstuvwxyz\n", 3001)
+
+	testCases := []struct {
+		name     string
+		splitter func(parallel int) FileSplitter
+	}{
+		{"Bytes", func(parallel int) FileSplitter { return ByteSplitter{separateByteStr: "4K", parallel: parallel} }},
+		{"BytesUneven", func(parallel int) FileSplitter { return ByteSplitter{separateByteStr: "1000", parallel: parallel} }},
+		{"Pieces", func(parallel int) FileSplitter { return PieceSplitter{chunkStr: "7", parallel: parallel} }},
+		{"MorePiecesThanBytes", func(parallel int) FileSplitter { return PieceSplitter{chunkStr: "200000", parallel: parallel} }},
+	}
+
+	for _, tc := range testCases {
+		sequential := &MemorySink{}
+		if err := tc.splitter(0).Split(strings.NewReader(data), sequential); err != nil {
+			t.Fatal(err)
+		}
+		for _, parallel := range []int{2, 4, 64} {
+			t.Run(fmt.Sprintf("%s/%d", tc.name, parallel), func(t *testing.T) {
+				sink := &MemorySink{}
+				if err := tc.splitter(parallel).Split(strings.NewReader(data), sink); err != nil {
+					t.Fatal(err)
+				}
+				if len(sink.Chunks) != len(sequential.Chunks) {
+					t.Fatal("Incorrect number of chunks. Expected ", len(sequential.Chunks), ", got ", len(sink.Chunks))
+				}
+				for i, chunk := range sink.Chunks {
+					if !bytes.Equal(chunk.Bytes(), sequential.Chunks[i].Bytes()) {
+						t.Fatal("Incorrect content of chunk ", i)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestCopyChunksEmptyInput(t *testing.T) {
+	sink := &MemorySink{}
+	if err := (ByteSplitter{separateByteStr: "10", parallel: 4}).Split(strings.NewReader(""), sink); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.Chunks) != 0 {
+		t.Fatal("Incorrect number of chunks. Expected 0, got ", len(sink.Chunks))
+	}
+}
+
+// failingSink fails to open the chunks from index fail on.
+type failingSink struct {
+	MemorySink
+	fail int
+}
+
+func (sink *failingSink) Open(index int) (io.WriteCloser, error) {
+	if index >= sink.fail {
+		return nil, fmt.Errorf("chunk %d failed", index)
+	}
+	return sink.MemorySink.Open(index)
+}
+
+func TestCopyChunksReturnsFirstError(t *testing.T) {
+	data := strings.Repeat("x", 1000)
+	sink := &failingSink{fail: 3}
+	err := copyChunks(inputRange{SectionReader: io.NewSectionReader(strings.NewReader(data), 0, int64(len(data)))}, sink, 10, 8, memoryBudget{})
+	if err == nil || err.Error() != "chunk 3 failed" {
+		t.Fatal("Incorrect error. Expected chunk 3 failed, got ", err)
+	}
+	for i := 0; i < 3; i++ {
+		if i >= len(sink.Chunks) || sink.Chunks[i].String() != data[i*10:(i+1)*10] {
+			t.Fatal("Incorrect content of chunk ", i)
+		}
+	}
+}
+
+func TestByteSplitterParallelFromPipe(t *testing.T) {
+	// A stream cannot be read at any offset, so it is copied one chunk after another.
+	data := strings.Repeat("abc", 100)
+	sink := &MemorySink{}
+	reader := io.MultiReader(strings.NewReader(data))
+	if err := (ByteSplitter{separateByteStr: "7", parallel: 4}).Split(reader, sink); err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	for _, chunk := range sink.Chunks {
+		output.Write(chunk.Bytes())
+	}
+	if output.String() != data {
+		t.Fatal("Incorrect chunk content.")
+	}
+}
+
+func TestByteSplittersFileToFile(t *testing.T) {
+	data := strings.Repeat("0123456789abcdefghijklmnopqrstuvwxyz\n", 3001)
+	input, err := os.CreateTemp("", "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(input.Name())
+	defer input.Close()
+	if _, err := input.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		splitter FileSplitter
+	}{
+		{"Bytes", ByteSplitter{separateByteStr: "10000"}},
+		{"BytesParallel", ByteSplitter{separateByteStr: "10000", parallel: 4}},
+		{"Pieces", PieceSplitter{chunkStr: "7"}},
+		{"PiecesParallel", PieceSplitter{chunkStr: "7", parallel: 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The input is split from its current offset.
+			if _, err := input.Seek(37, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			prefix := filepath.Join(t.TempDir(), "x")
+			if err := tc.splitter.Split(input, NewFileSink(NewAlphabetFileNameCreater(2, prefix))); err != nil {
+				t.Fatal(err)
+			}
+			names, err := filepath.Glob(prefix + "*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var output bytes.Buffer
+			for _, name := range names {
+				content, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				output.Write(content)
+			}
+			if output.String() != data[37:] {
+				t.Fatal("Incorrect chunk content. Expected ", len(data)-37, " bytes, got ", output.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package split
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// defaultSeparator ends the lines when no separator is given.
+const defaultSeparator = "\n"
+
+// parseSeparator returns the bytes of a separator specification (-t). It can
+// be any number of bytes, with the escapes \0, \t, \n, \r and \\. An empty
+// specification returns the zero value, which means a newline.
+func parseSeparator(separatorStr string) (string, error) {
+	if !strings.Contains(separatorStr, `\`) {
+		return separatorStr, nil
+	}
+
+	var separator strings.Builder
+	for i := 0; i < len(separatorStr); i++ {
+		if separatorStr[i] != '\\' {
+			separator.WriteByte(separatorStr[i])
+			continue
+		}
+		i++
+		if i == len(separatorStr) {
+			return "", fmt.Errorf(separatorInvalidErrorMsg, separatorStr)
+		}
+		switch separatorStr[i] {
+		case '0':
+			separator.WriteByte(0)
+		case 't':
+			separator.WriteByte('\t')
+		case 'n':
+			separator.WriteByte('\n')
+		case 'r':
+			separator.WriteByte('\r')
+		case '\\':
+			separator.WriteByte('\\')
+		default:
+			return "", fmt.Errorf(separatorInvalidErrorMsg, separatorStr)
+		}
+	}
+	return separator.String(), nil
+}
+
+// lineSeparator returns separator, or a newline for the zero value.
+func lineSeparator(separator string) string {
+	if separator == "" {
+		return defaultSeparator
+	}
+	return separator
+}
+
+// recordCounter counts the lines ending with separator in the bytes written
+// to it, including separators split across writes. Like the splitters, it
+// matches the separators from left to right without overlapping.
+type recordCounter struct {
+	separator string
+	// tail holds the last bytes after the last separator that could start one.
+	tail   []byte
+	length int64
+	count  int64
+	// ended tells whether the bytes so far end with a separator.
+	ended bool
+}
+
+func newRecordCounter(separator string) *recordCounter {
+	return &recordCounter{separator: lineSeparator(separator)}
+}
+
+func (c *recordCounter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	c.length += int64(len(p))
+	separator := []byte(c.separator)
+	if len(separator) == 1 {
+		c.count += int64(bytes.Count(p, separator))
+		c.ended = p[len(p)-1] == separator[0]
+		return len(p), nil
+	}
+
+	// rest is the part of p after the last separator found.
+	rest := p
+	matched := false
+	if len(c.tail) > 0 {
+		window := append(c.tail, p[:min(len(p), len(separator)-1)]...)
+		if i := bytes.Index(window, separator); i >= 0 {
+			c.count++
+			rest = p[i+len(separator)-len(c.tail):]
+			matched = true
+		}
+	}
+	for {
+		i := bytes.Index(rest, separator)
+		if i < 0 {
+			break
+		}
+		c.count++
+		rest = rest[i+len(separator):]
+		matched = true
+	}
+
+	c.ended = matched && len(rest) == 0
+	if !matched {
+		rest = append(c.tail, rest...)
+	}
+	if len(rest) > len(separator)-1 {
+		rest = rest[len(rest)-(len(separator)-1):]
+	}
+	c.tail = append(c.tail[:0:0], rest...)
+	return len(p), nil
+}
+
+// lines returns the number of lines, counting a last line without separator.
+func (c *recordCounter) lines() int64 {
+	if c.length > 0 && !c.ended {
+		return c.count + 1
+	}
+	return c.count
+}
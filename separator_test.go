@@ -0,0 +1,130 @@
+package split
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseSeparator(t *testing.T) {
+	testCases := []struct {
+		separatorStr string
+		expected     string
+		err          error
+	}{
+		{"", "", nil},
+		{",", ",", nil},
+		{`\0`, "\x00", nil},
+		{`\t`, "\t", nil},
+		{`\n---\n`, "\n---\n", nil},
+		{`a\\b\r\n`, "a\\b\r\n", nil},
+		{`\x`, "", fmt.Errorf(separatorInvalidErrorMsg, `\x`)},
+		{`abc\`, "", fmt.Errorf(separatorInvalidErrorMsg, `abc\`)},
+	}
+
+	for _, tc := range testCases {
+		separator, err := parseSeparator(tc.separatorStr)
+		if tc.err != nil {
+			if err == nil || err.Error() != tc.err.Error() {
+				t.Errorf("Input: %q, Expected error: %v, Got: %v", tc.separatorStr, tc.err, err)
+			}
+			continue
+		}
+		if err != nil || separator != tc.expected {
+			t.Errorf("Input: %q, Expected: %q, Got: %q, %v", tc.separatorStr, tc.expected, separator, err)
+		}
+	}
+}
+
+func TestRecordCounter(t *testing.T) {
+	testCases := []struct {
+		input     string
+		separator string
+		count     int64
+		lines     int64
+	}{
+		{"", "\n", 0, 0},
+		{"a\nb", "\n", 1, 2},
+		{"a\x00b\x00", "\x00", 2, 2},
+		{"a\n---\nb\n---\n", "\n---\n", 2, 2},
+		// Separators are matched from left to right without overlapping.
+		{"x\n---\n---\n", "\n---\n", 1, 2},
+		{"aaaa", "aa", 2, 2},
+		{"aaa", "aa", 1, 2},
+	}
+
+	for _, tc := range testCases {
+		// Every way of writing the input in two parts must count the same.
+		for cut := 0; cut <= len(tc.input); cut++ {
+			counter := newRecordCounter(tc.separator)
+			counter.Write([]byte(tc.input[:cut]))
+			counter.Write([]byte(tc.input[cut:]))
+			if counter.count != tc.count || counter.lines() != tc.lines {
+				t.Errorf("Input: %q cut at %d, Expected: %d separators and %d lines, Got: %d and %d", tc.input, cut, tc.count, tc.lines, counter.count, counter.lines())
+			}
+		}
+		// And a byte at a time.
+		counter := newRecordCounter(tc.separator)
+		for i := 0; i < len(tc.input); i++ {
+			counter.Write([]byte{tc.input[i]})
+		}
+		if counter.count != tc.count || counter.lines() != tc.lines {
+			t.Errorf("Input: %q by byte, Expected: %d separators and %d lines, Got: %d and %d", tc.input, tc.count, tc.lines, counter.count, counter.lines())
+		}
+	}
+}
+
+func TestSplittersWithSeparator(t *testing.T) {
+	nul := "a\x00bb\x00ccc\x00dddd\x00e"
+	yaml := "a: 1\n---\nb: 2\nc: 3\n---\n---\nd: 4\n"
+
+	testCases := []struct {
+		options  Options
+		input    string
+		expected []string
+	}{
+		{Options{Lines: 2, Separator: `\0`}, nul, []string{"a\x00bb\x00", "ccc\x00dddd\x00", "e"}},
+		{Options{Chunks: "l/2", Separator: `\0`}, nul, []string{"a\x00bb\x00ccc\x00", "dddd\x00e"}},
+		{Options{Chunks: "r/2", Separator: `\0`}, nul, []string{"a\x00ccc\x00e", "bb\x00dddd\x00"}},
+		{Options{Lines: 1, Separator: `\n---\n`}, yaml, []string{"a: 1\n---\n", "b: 2\nc: 3\n---\n", "---\nd: 4\n"}},
+		{Options{Chunks: "r/2", Separator: `\n---\n`}, yaml, []string{"a: 1\n---\n---\nd: 4\n", "b: 2\nc: 3\n---\n"}},
+		{Options{Lines: 2, Separator: `\t`}, "a\tb\tc", []string{"a\tb\t", "c"}},
+	}
+
+	for _, tc := range testCases {
+		splitter, err := NewSplitter(tc.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		memory := &MemorySink{}
+		if err := splitter.Split(strings.NewReader(tc.input), memory); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, chunk := range memory.Chunks {
+			got = append(got, chunk.String())
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tc.expected) {
+			t.Errorf("Options: %+v, Expected: %q, Got: %q", tc.options, tc.expected, got)
+		}
+
+		joiner, err := NewJoiner(memorySource{memory}, tc.options.Chunks, tc.options.Separator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var joined strings.Builder
+		if _, err := joiner.Join(&joined); err != nil {
+			t.Fatal(err)
+		}
+		if joined.String() != tc.input {
+			t.Errorf("Options: %+v, Joined content is not the original: %q", tc.options, joined.String())
+		}
+	}
+}
+
+func TestNewSplitterRejectsInvalidSeparator(t *testing.T) {
+	_, err := NewSplitter(Options{Separator: `\q`})
+	if err == nil || err.Error() != fmt.Sprintf(separatorInvalidErrorMsg, `\q`) {
+		t.Fatal("Expected an invalid separator error, got ", err)
+	}
+}
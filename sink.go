@@ -0,0 +1,95 @@
+package split
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ChunkSink opens the destination of every chunk a FileSplitter produces.
+// Chunks are opened only when they have data, in increasing index order
+// unless several chunks are copied at once, see Options.Parallel. The sink
+// must then be safe for concurrent use.
+type ChunkSink interface {
+	Open(index int) (io.WriteCloser, error)
+}
+
+// FileSink writes every chunk to the file named by its FileNameCreater.
+type FileSink struct {
+	fileNameCreater FileNameCreater
+}
+
+func (sink FileSink) Open(index int) (io.WriteCloser, error) {
+	outputFilePath, err := sink.fileNameCreater.Create(index)
+	if err != nil {
+		return nil, err
+	}
+	outFile, err := os.Create(outputFilePath)
+	if err != nil {
+		return nil, fmt.Errorf(createFileErrorMsg, err)
+	}
+	return outFile, nil
+}
+
+func (sink FileSink) checkChunks(count int64) error {
+	return checkChunkCount(sink.fileNameCreater, count)
+}
+
+// prepareOutputDir creates the OutputDir of options when it is missing, and
+// checks that the chunks can be created in the directory of their names
+// before any input is read.
+func prepareOutputDir(options Options) error {
+	if options.OutputDir != "" {
+		if err := os.MkdirAll(options.OutputDir, 0777); err != nil {
+			return fmt.Errorf(outputDirErrorMsg, err)
+		}
+	}
+	suffix := suffixOf(options)
+	// The prefix may end with a slash.
+	dir := filepath.Dir(suffix.Prefix + "x")
+	if suffix.Template != "" {
+		dir = filepath.Dir(suffix.Template)
+	}
+	if strings.ContainsAny(dir, "{}") {
+		// The directory depends on the chunk.
+		return nil
+	}
+	file, err := os.CreateTemp(dir, ".split*")
+	if err != nil {
+		return fmt.Errorf(outputDirNotWritableErrorMsg, err)
+	}
+	file.Close()
+	os.Remove(file.Name())
+	return nil
+}
+
+// MemorySink keeps every chunk in memory. Chunks[i] holds chunk i.
+type MemorySink struct {
+	Chunks []*bytes.Buffer
+	mu     sync.Mutex
+}
+
+func (sink *MemorySink) Open(index int) (io.WriteCloser, error) {
+	if index < 0 {
+		return nil, fmt.Errorf(negativeFileNumberErrorMsg)
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	for len(sink.Chunks) <= index {
+		sink.Chunks = append(sink.Chunks, nil)
+	}
+	sink.Chunks[index] = &bytes.Buffer{}
+	return nopWriteCloser{sink.Chunks[index]}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,216 @@
+package split
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSinkOpen(t *testing.T) {
+
+	defer deleteOutputFiles()
+	sink := FileSink{AlphabetFileNameCreater{digit: 2, prefix: "output"}}
+
+	outFile, err := sink.Open(27)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := outFile.Write([]byte("chunk")); err != nil {
+		t.Fatal(err)
+	}
+	if err := outFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile("outputbb")
+	if err != nil {
+		t.Fatal("outputbb file was not created.")
+	}
+	if string(content) != "chunk" {
+		t.Fatal("outputbb file has incorrect content. Expected chunk, got ", string(content))
+	}
+}
+
+func TestSplittersCheckSuffixUpFront(t *testing.T) {
+
+	defer deleteOutputFiles()
+	data := strings.Repeat("0123456789\n", 10)
+	sink := FileSink{AlphabetFileNameCreater{digit: 1, prefix: "output"}}
+
+	testCases := []struct {
+		name     string
+		splitter FileSplitter
+	}{
+		{"ByteSplitter", ByteSplitter{separateByteStr: "3"}},
+		{"PieceByteSplitter", PieceSplitter{chunkStr: "27"}},
+		{"PieceLineSplitter", PieceSplitter{chunkStr: "l/27"}},
+		{"PieceLineRoundRobinSplitter", PieceSplitter{chunkStr: "r/27"}},
+	}
+
+	for _, tc := range testCases {
+		err := tc.splitter.Split(strings.NewReader(data), sink)
+		if err == nil || err.Error() != tooBigFileNumberErrorMsg {
+			t.Fatal(tc.name, ": Expected ", tooBigFileNumberErrorMsg, ", got ", err)
+		}
+		if countFiles() != 0 {
+			t.Fatal(tc.name, ": A file was created before the suffix check.")
+		}
+	}
+}
+
+func TestFileSinkOpenTooBigFileNumber(t *testing.T) {
+
+	defer deleteOutputFiles()
+	sink := FileSink{AlphabetFileNameCreater{digit: 1, prefix: "output"}}
+
+	_, err := sink.Open(26)
+	if err == nil || err.Error() != tooBigFileNumberErrorMsg {
+		t.Fatal("Expected ", tooBigFileNumberErrorMsg, ", got ", err)
+	}
+	if countFiles() != 0 {
+		t.Fatal("A file was created for an invalid file number.")
+	}
+}
+
+func TestMemorySinkWithSplitters(t *testing.T) {
+	var lines strings.Builder
+	for i := 1; i <= 2007; i++ {
+		fmt.Fprintln(&lines, "line", i)
+	}
+	data := lines.String()
+
+	testCases := []struct {
+		name           string
+		splitter       FileSplitter
+		expectedChunks int
+		concatenated   bool
+	}{
+		{"LineSplitter", LineSplitter{separateLineNumber: 1000}, 3, true},
+		{"ByteSplitter", ByteSplitter{separateByteStr: "5k"}, 4, true},
+		{"PieceByteSplitter", PieceSplitter{chunkStr: "4"}, 4, true},
+		{"ByteSplitterParallel", ByteSplitter{separateByteStr: "1k", parallel: 3}, 19, true},
+		{"PieceByteSplitterParallel", PieceSplitter{chunkStr: "4", parallel: 3}, 4, true},
+		{"PieceLineSplitter", PieceSplitter{chunkStr: "l/4"}, 4, true},
+		{"PieceLineRoundRobinSplitter", PieceSplitter{chunkStr: "r/4"}, 4, false},
+	}
+
+	for _, tc := range testCases {
+		// The seekable reader goes through random access, the other one is spooled.
+		readers := map[string]io.Reader{
+			"seekable": strings.NewReader(data),
+			"stream":   io.MultiReader(strings.NewReader(data)),
+		}
+		for kind, reader := range readers {
+			t.Run(tc.name+"/"+kind, func(t *testing.T) {
+				sink := &MemorySink{}
+				if err := tc.splitter.Split(reader, sink); err != nil {
+					t.Fatal(err)
+				}
+				if len(sink.Chunks) != tc.expectedChunks {
+					t.Fatal("Incorrect number of chunks. Expected ", tc.expectedChunks, ", got ", len(sink.Chunks))
+				}
+				var output bytes.Buffer
+				for _, chunk := range sink.Chunks {
+					output.Write(chunk.Bytes())
+				}
+				if output.Len() != len(data) {
+					t.Fatal("Incorrect total chunk size. Expected ", len(data), ", got ", output.Len())
+				}
+				if tc.concatenated && output.String() != data {
+					t.Fatal("Incorrect chunk content.")
+				}
+			})
+		}
+	}
+}
+
+func TestSectionOfKeepsReaderOffset(t *testing.T) {
+	reader := strings.NewReader("skipped|section")
+	if _, err := reader.Seek(8, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	section, cleanup, err := sectionOf(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	if section.Size() != 7 {
+		t.Fatal("Incorrect section size. Expected 7, got ", section.Size())
+	}
+	content, err := io.ReadAll(section)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "section" {
+		t.Fatal("Incorrect section content. Expected section, got ", string(content))
+	}
+}
+
+func TestNewChunkSinkOutputDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "parts", "orders")
+	data := "line 1\nline 2\nline 3\n"
+	options := Options{Lines: 2, Prefix: "orders-", AdditionalSuffix: ".csv", OutputDir: dir, Compress: "gzip"}
+	splitter, err := NewSplitter(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewChunkSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := splitter.Split(strings.NewReader(data), sink); err != nil {
+		t.Fatal(err)
+	}
+
+	var output []byte
+	for _, name := range []string{"orders-aa.csv.gz", "orders-ab.csv.gz"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(name, " was not created.")
+		}
+		output = append(output, gunzip(t, content)...)
+	}
+	if string(output) != data {
+		t.Fatal("Incorrect output file content.")
+	}
+}
+
+func TestNewChunkSinkOutputDirErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		options Options
+		err     string
+	}{
+		{Options{AdditionalSuffix: "/csv"}, fmt.Sprintf(additionalSuffixInvalidErrorMsg, "/csv")},
+		{Options{Prefix: filepath.Join(dir, "missing", "x")}, outputDirNotWritableErrorMsg},
+		{Options{OutputDir: dir, Prefix: "missing/x"}, outputDirNotWritableErrorMsg},
+		{Options{NameTemplate: filepath.Join(dir, "missing", "{index}")}, outputDirNotWritableErrorMsg},
+		{Options{OutputDir: filepath.Join(file, "dir")}, outputDirErrorMsg},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewChunkSink(tc.options)
+		if err == nil || !strings.HasPrefix(err.Error(), strings.TrimSuffix(tc.err, "%w")) {
+			t.Errorf("Input: %+v, Expected: %s, Got: %v", tc.options, tc.err, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected only %s in %s, Got: %v", file, dir, entries)
+	}
+
+	// A filter creates no file, so the directory is not checked.
+	if _, err := NewChunkSink(Options{Filter: "cat", Prefix: filepath.Join(dir, "missing", "x")}); err != nil {
+		t.Errorf("Expected no error, Got: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build linux
+
+package split
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copySparse copies size bytes of input from offset to outFile, seeking over
+// the holes of the input instead of writing their zeros, so that the chunk is
+// as sparse as the input and still reads the same. It reports false without
+// writing anything when the range has no hole or the file system cannot find
+// them.
+func copySparse(outFile *os.File, input inputRange, offset int64, size int64, buffer []byte) (bool, error) {
+	end := offset + size
+	hole, err := seekFile(input.file, input.base+offset, unix.SEEK_HOLE)
+	if err != nil || hole-input.base >= end {
+		return false, nil
+	}
+
+	for position := offset; position < end; {
+		data, err := seekFile(input.file, input.base+position, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			// The rest of the file is a hole.
+			break
+		}
+		if err != nil {
+			return true, fmt.Errorf(fileReadErrorMsg, err)
+		}
+		data -= input.base
+		if data >= end {
+			break
+		}
+		hole, err := seekFile(input.file, input.base+data, unix.SEEK_HOLE)
+		if err != nil {
+			return true, fmt.Errorf(fileReadErrorMsg, err)
+		}
+		hole = min(hole-input.base, end)
+
+		if _, err := outFile.Seek(data-offset, io.SeekStart); err != nil {
+			return true, fmt.Errorf(fileWriteErrorMsg, err)
+		}
+		copied := copyFileRange(outFile, input.file, input.base+data, hole-data)
+		if err := copyRangeBuffer(outFile, input.SectionReader, data+copied, hole-data-copied, buffer); err != nil {
+			return true, err
+		}
+		position = hole
+	}
+	// A hole at the end of the chunk has no data to extend it.
+	if err := outFile.Truncate(size); err != nil {
+		return true, fmt.Errorf(fileWriteErrorMsg, err)
+	}
+	return true, nil
+}
+
+// seekFile returns the offset lseek finds from offset with whence, without
+// changing what file reads next through ReadAt.
+func seekFile(file *os.File, offset int64, whence int) (int64, error) {
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var found int64
+	var seekErr error
+	if err := conn.Control(func(fd uintptr) {
+		found, seekErr = unix.Seek(int(fd), offset, whence)
+	}); err != nil {
+		return 0, err
+	}
+	return found, seekErr
+}
@@ -0,0 +1,138 @@
+//go:build linux
+
+package split
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// allocatedSize returns the bytes the file system allocated for the file.
+func allocatedSize(t *testing.T, name string) int64 {
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Sys().(*syscall.Stat_t).Blocks * 512
+}
+
+// sparseImageSize is the size of the image of sparseImage.
+const sparseImageSize = 8 * 1024 * 1024
+
+// sparseImage returns an image that is a hole with data at 3M and at its last
+// bytes, and its content. It skips the test when the file system does not
+// keep holes.
+func sparseImage(t *testing.T) (*os.File, []byte) {
+	t.Helper()
+	const size = sparseImageSize
+	data := bytes.Repeat([]byte("data"), 16*1024)
+	input, err := os.Create(filepath.Join(t.TempDir(), "image"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { input.Close() })
+	// The image is a hole with data at 3M and at its last bytes.
+	if err := input.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := input.WriteAt(data, 3*1024*1024); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := input.WriteAt(data[:100], size-100); err != nil {
+		t.Fatal(err)
+	}
+	if allocatedSize(t, input.Name()) >= size {
+		t.Skip("The file system does not keep holes.")
+	}
+	image, err := os.ReadFile(input.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input, image
+}
+
+func TestByteSplittersKeepHoles(t *testing.T) {
+	const size = sparseImageSize
+	input, image := sparseImage(t)
+
+	testCases := []struct {
+		name     string
+		splitter FileSplitter
+		chunks   int
+	}{
+		{"Bytes", ByteSplitter{separateByteStr: "2M"}, 4},
+		{"BytesParallel", ByteSplitter{separateByteStr: "2M", parallel: 4}, 4},
+		{"Pieces", PieceSplitter{chunkStr: "3"}, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := input.Seek(0, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			prefix := filepath.Join(t.TempDir(), "x")
+			if err := tc.splitter.Split(input, NewFileSink(NewAlphabetFileNameCreater(2, prefix))); err != nil {
+				t.Fatal(err)
+			}
+			names, err := filepath.Glob(prefix + "*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(names) != tc.chunks {
+				t.Fatal("Incorrect number of chunks. Expected ", tc.chunks, ", got ", len(names))
+			}
+			var joined bytes.Buffer
+			var allocated int64
+			for _, name := range names {
+				content, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				joined.Write(content)
+				allocated += allocatedSize(t, name)
+			}
+			if !bytes.Equal(joined.Bytes(), image) {
+				t.Fatal("Incorrect chunk content.")
+			}
+			if allocated >= size/2 {
+				t.Fatal("Chunks are not sparse. Expected less than ", size/2, " bytes allocated, got ", allocated)
+			}
+		})
+	}
+}
+
+func TestByteSplittersKeepHolesWithManifest(t *testing.T) {
+	input, image := sparseImage(t)
+
+	for _, options := range []Options{{Bytes: "2M"}, {Chunks: "3"}, {Bytes: "2M", ContentNames: true}} {
+		if _, err := input.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		options.OutputDir = t.TempDir()
+		manifest := splitWithManifest(t, options, input)
+
+		var joined bytes.Buffer
+		var allocated int64
+		for _, chunk := range manifest.Chunks {
+			content, err := os.ReadFile(chunk.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chunk.Length != int64(len(content)) || chunk.SHA256 != sha256Hex(content) {
+				t.Errorf("Options: %+v, Chunk %d does not describe the file: %+v", options, chunk.Index, chunk)
+			}
+			joined.Write(content)
+			allocated += allocatedSize(t, chunk.Name)
+		}
+		if !bytes.Equal(joined.Bytes(), image) {
+			t.Fatalf("Options: %+v, Incorrect chunk content.", options)
+		}
+		if allocated >= sparseImageSize/2 {
+			t.Errorf("Options: %+v, Chunks are not sparse. Expected less than %d bytes allocated, got %d", options, sparseImageSize/2, allocated)
+		}
+	}
+}
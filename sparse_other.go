@@ -0,0 +1,11 @@
+//go:build !linux
+
+package split
+
+import "os"
+
+// copySparse finds no holes outside Linux, and the caller copies the range
+// densely.
+func copySparse(outFile *os.File, input inputRange, offset int64, size int64, buffer []byte) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,397 @@
+package split
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The placeholders of a name template. {index}, {total}, {offset},
+// {first_line} and {last_line} take a format after a colon: a width the
+// number is padded to and a base, d (decimal, the default), x (hexadecimal)
+// or a (letters like the alphabetic suffixes), such as {index:04} or
+// {index:3a}. g is decimal that grows past the width like the suffixes
+// without -a, 89 then 9000 for {index:2g}, so that the names still sort.
+const (
+	templateIndex     = "index"
+	templateTotal     = "total"
+	templateName      = "name"
+	templateExtension = "ext"
+	templateOffset    = "offset"
+	templateFirstLine = "first_line"
+	templateLastLine  = "last_line"
+	templateSHA256    = "sha256"
+)
+
+// templatePart is a literal or a placeholder of a name template.
+type templatePart struct {
+	literal string
+	field   string
+	width   int
+	base    int
+	grow    bool
+}
+
+// TemplateFileNameCreater names the chunks by a template such as
+// "{name}.part{index:04}-of-{total:04}{ext}". {index} is the chunk index plus
+// the start, {total} the number of chunks when the splitter knows it up front
+// (Bytes of a seekable input and Chunks N), and {name} and {ext} the base name
+// of the input without and with only its extension, such as "orders" and
+// ".csv". {offset}, {first_line} and {last_line} are the byte offset and the
+// line range of the chunk in the input, and {sha256} the hex SHA-256 of the
+// chunk as stored, known once the chunk is written: Create then creates an
+// empty file of a unique temporary name next to the chunk and returns it, and
+// the chunk is renamed on Close by the sink of NewChunkSink. "{{" and "}}" are literal braces.
+type TemplateFileNameCreater struct {
+	parts     []templatePart
+	inputName string
+	// start is the index of chunk 0.
+	start int
+	// total is the number of chunks, 0 until a splitter tells it.
+	total int64
+	// extension is what the sink appends to the names, such as ".gz", and
+	// the temporary files are reserved with.
+	extension string
+}
+
+// NewTemplateFileNameCreater returns a TemplateFileNameCreater for template.
+// inputName is the input {name} and {ext} come from; "-" and "" mean stdin.
+func NewTemplateFileNameCreater(template string, inputName string) (*TemplateFileNameCreater, error) {
+	parts, err := parseTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateFileNameCreater{parts: parts, inputName: inputName}, nil
+}
+
+func parseTemplate(template string) ([]templatePart, error) {
+	var parts []templatePart
+	var literal strings.Builder
+	for i := 0; i < len(template); i++ {
+		switch {
+		case strings.HasPrefix(template[i:], "{{"), strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte(template[i])
+			i++
+		case template[i] == '}':
+			return nil, fmt.Errorf(templateInvalidErrorMsg, template)
+		case template[i] == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf(templateInvalidErrorMsg, template)
+			}
+			part, ok := parsePlaceholder(template[i+1 : i+end])
+			if !ok {
+				return nil, fmt.Errorf(templateInvalidErrorMsg, template)
+			}
+			if literal.Len() > 0 {
+				parts = append(parts, templatePart{literal: literal.String()})
+				literal.Reset()
+			}
+			parts = append(parts, part)
+			i += end
+		default:
+			literal.WriteByte(template[i])
+		}
+	}
+	if literal.Len() > 0 {
+		parts = append(parts, templatePart{literal: literal.String()})
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf(templateInvalidErrorMsg, template)
+	}
+	return parts, nil
+}
+
+// parsePlaceholder parses the inside of the braces of a placeholder, such as
+// "index:04".
+func parsePlaceholder(placeholder string) (templatePart, bool) {
+	field, format, formatted := strings.Cut(placeholder, ":")
+	part := templatePart{field: field, base: 10}
+	switch field {
+	case templateName, templateExtension, templateSHA256:
+		return part, !formatted
+	case templateIndex, templateTotal, templateOffset, templateFirstLine, templateLastLine:
+	default:
+		return templatePart{}, false
+	}
+
+	if format != "" {
+		switch format[len(format)-1] {
+		case 'd':
+			format = format[:len(format)-1]
+		case 'x':
+			part.base = 16
+			format = format[:len(format)-1]
+		case 'a':
+			part.base = 26
+			format = format[:len(format)-1]
+		case 'g':
+			part.grow = true
+			format = format[:len(format)-1]
+		}
+	}
+	if format != "" {
+		width, err := strconv.ParseUint(format, 10, 8)
+		if err != nil {
+			return templatePart{}, false
+		}
+		part.width = int(width)
+	}
+	return part, true
+}
+
+// templateChunk is what is known of a chunk once it is written.
+type templateChunk struct {
+	offset    int64
+	firstLine int64
+	lastLine  int64
+	sum       string
+}
+
+// deferred reports whether the template has placeholders known only once the
+// chunk is written.
+func (fileNameCreater *TemplateFileNameCreater) deferred() bool {
+	return fileNameCreater.uses(templateOffset, templateFirstLine, templateLastLine, templateSHA256)
+}
+
+// uses reports whether the template has any of the placeholders of fields.
+func (fileNameCreater *TemplateFileNameCreater) uses(fields ...string) bool {
+	for _, part := range fileNameCreater.parts {
+		for _, field := range fields {
+			if part.field == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (fileNameCreater *TemplateFileNameCreater) Create(fileNumber int) (string, error) {
+	if fileNumber < 0 {
+		return "", fmt.Errorf(negativeFileNumberErrorMsg)
+	}
+	if fileNameCreater.total > 0 && int64(fileNumber) >= fileNameCreater.total {
+		return "", fmt.Errorf(tooBigFileNumberErrorMsg)
+	}
+	if !fileNameCreater.deferred() {
+		return fileNameCreater.render(fileNumber, nil)
+	}
+	// The chunk is written next to where it goes, and renamed once written.
+	// The temporary name is reserved, so that another split writing to the
+	// same directory or a file already there is never overwritten.
+	fileName, err := fileNameCreater.render(fileNumber, &templateChunk{})
+	if err != nil {
+		return "", err
+	}
+	temporary, err := os.CreateTemp(filepath.Dir(fileName), fmt.Sprintf(".split%d-*.tmp%s", fileNumber, fileNameCreater.extension))
+	if err != nil {
+		return "", fmt.Errorf(createFileErrorMsg, err)
+	}
+	temporary.Close()
+	return strings.TrimSuffix(temporary.Name(), fileNameCreater.extension), nil
+}
+
+// checkChunks records the number of chunks for {total}. Any number of
+// chunks can be named.
+func (fileNameCreater *TemplateFileNameCreater) checkChunks(count int64) error {
+	fileNameCreater.total = count
+	return nil
+}
+
+// render returns the name of chunk fileNumber. chunk is nil until the chunk
+// is written.
+func (fileNameCreater *TemplateFileNameCreater) render(fileNumber int, chunk *templateChunk) (string, error) {
+	base := filepath.Base(fileNameCreater.inputName)
+	if fileNameCreater.inputName == "" || fileNameCreater.inputName == "-" {
+		base = "stdin"
+	}
+	extension := filepath.Ext(base)
+
+	var fileName strings.Builder
+	for _, part := range fileNameCreater.parts {
+		var value int64
+		switch part.field {
+		case "":
+			fileName.WriteString(part.literal)
+			continue
+		case templateName:
+			fileName.WriteString(strings.TrimSuffix(base, extension))
+			continue
+		case templateExtension:
+			fileName.WriteString(extension)
+			continue
+		case templateSHA256:
+			if chunk == nil {
+				return "", fmt.Errorf(templateDeferredErrorMsg, part.field)
+			}
+			fileName.WriteString(chunk.sum)
+			continue
+		case templateIndex:
+			value = int64(fileNameCreater.start + fileNumber)
+		case templateTotal:
+			if fileNameCreater.total == 0 {
+				return "", fmt.Errorf(templateTotalUnknownErrorMsg)
+			}
+			value = fileNameCreater.total
+		case templateOffset, templateFirstLine, templateLastLine:
+			if chunk == nil {
+				return "", fmt.Errorf(templateDeferredErrorMsg, part.field)
+			}
+			value = chunk.offset
+			if part.field == templateFirstLine {
+				value = chunk.firstLine
+			} else if part.field == templateLastLine {
+				value = chunk.lastLine
+			}
+		}
+		if part.grow {
+			value, width, grown := growSuffix(int(value), max(part.width, 1), 10)
+			fileName.WriteString(strings.Repeat("9", grown) + formatTemplateNumber(int64(value), width, 10))
+			continue
+		}
+		fileName.WriteString(formatTemplateNumber(value, part.width, part.base))
+	}
+	return fileName.String(), nil
+}
+
+// formatTemplateNumber formats value in base, padded to width digits.
+func formatTemplateNumber(value int64, width int, base int) string {
+	if base != 26 {
+		digits := strconv.FormatInt(value, base)
+		if len(digits) < width {
+			digits = strings.Repeat("0", width-len(digits)) + digits
+		}
+		return digits
+	}
+	var digits string
+	for len(digits) < max(width, 1) || value > 0 {
+		digits = string(rune('a'+value%26)) + digits
+		value /= 26
+	}
+	return digits
+}
+
+// templateSink renames every chunk of sink to its name by template once it is
+// written, for the placeholders known only then. For the offset and line
+// placeholders, the chunks must be written one after another, so that the
+// offset and the lines before each are known.
+type templateSink struct {
+	sink      ChunkSink
+	template  *TemplateFileNameCreater
+	extension string
+	separator string
+	// listing receives the index, the SHA-256 and the name of every chunk
+	// renamed, when not nil.
+	listing io.Writer
+
+	mu          sync.Mutex
+	offset      int64
+	linesBefore int64
+}
+
+func (sink *templateSink) Open(index int) (io.WriteCloser, error) {
+	outFile, err := sink.sink.Open(index)
+	if err != nil {
+		return nil, err
+	}
+	name := ""
+	if named, ok := outFile.(interface{ Name() string }); ok {
+		name = named.Name()
+	}
+	return &templateWriteCloser{outFile: outFile, sink: sink, index: index, name: name, lines: newRecordCounter(sink.separator), hash: sha256.New()}, nil
+}
+
+func (sink *templateSink) checkChunks(count int64) error {
+	return checkChunkCount(sink.sink, count)
+}
+
+type templateWriteCloser struct {
+	outFile io.WriteCloser
+	sink    *templateSink
+	index   int
+	// name is the temporary name until the chunk is closed.
+	name   string
+	length int64
+	lines  *recordCounter
+	hash   hash.Hash
+	// size and sum are the size and checksum of the chunk as stored, once
+	// it is closed.
+	size int64
+	sum  []byte
+}
+
+func (w *templateWriteCloser) Write(p []byte) (int, error) {
+	n, err := w.outFile.Write(p)
+	if n > 0 {
+		w.written(p[:n])
+	}
+	return n, err
+}
+
+func (w *templateWriteCloser) written(p []byte) {
+	w.length += int64(len(p))
+	w.lines.Write(p)
+	w.hash.Write(p)
+}
+
+// Unwrap returns the file of the chunk, so that the byte splitters can copy
+// to it in the kernel and keep its holes.
+func (w *templateWriteCloser) Unwrap() *os.File {
+	return unwrapFile(w.outFile)
+}
+
+func (w *templateWriteCloser) record(p []byte) {
+	recordChunk(w.outFile, p)
+	w.written(p)
+}
+
+// storedDigest passes the stored digest of a compressed chunk on to the
+// manifest, which the chunk hides.
+func (w *templateWriteCloser) storedDigest() (int64, []byte) {
+	return w.size, w.sum
+}
+
+// Name is the name of the chunk, by the template once it is closed.
+func (w *templateWriteCloser) Name() string {
+	return w.name
+}
+
+func (w *templateWriteCloser) Close() error {
+	if err := w.outFile.Close(); err != nil {
+		return err
+	}
+	w.sum = w.hash.Sum(nil)
+	if stored, ok := w.outFile.(storedDigester); ok {
+		w.size, w.sum = stored.storedDigest()
+	}
+
+	w.sink.mu.Lock()
+	// The last line of a chunk may go on in the next chunk or have no newline.
+	chunk := templateChunk{w.sink.offset, w.sink.linesBefore + 1, w.sink.linesBefore + w.lines.lines(), hex.EncodeToString(w.sum)}
+	w.sink.offset += w.length
+	w.sink.linesBefore += w.lines.count
+	w.sink.mu.Unlock()
+
+	fileName, err := w.sink.template.render(w.index, &chunk)
+	if err != nil {
+		return err
+	}
+	fileName += w.sink.extension
+	if err := os.Rename(w.name, fileName); err != nil {
+		return fmt.Errorf(renameFileErrorMsg, err)
+	}
+	w.name = fileName
+	if w.sink.listing != nil {
+		if _, err := fmt.Fprintf(w.sink.listing, "%d %s %s\n", w.index, chunk.sum, fileName); err != nil {
+			return fmt.Errorf(fileWriteErrorMsg, err)
+		}
+	}
+	return nil
+}
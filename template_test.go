@@ -0,0 +1,324 @@
+package split
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestTemplateFileNameCreater(t *testing.T) {
+	testCases := []struct {
+		template         string
+		inputName        string
+		start            int
+		total            int64
+		fileNumber       int
+		expectedFileName string
+	}{
+		{"{name}.part{index:04}-of-{total:04}{ext}", "data/orders-2026-10-18.csv", 1, 120, 2, "orders-2026-10-18.part0003-of-0120.csv"},
+		{"chunk{index}", "", 0, 0, 12, "chunk12"},
+		{"chunk{index:3x}", "", 0, 0, 255, "chunk0ff"},
+		{"chunk{index:2a}", "", 0, 0, 27, "chunkbb"},
+		{"chunk{index:a}", "", 0, 0, 0, "chunka"},
+		{"chunk{index:2g}", "", 0, 0, 89, "chunk89"},
+		{"chunk{index:2g}", "", 0, 0, 90, "chunk9000"},
+		{"chunk{index:2g}", "", 1, 0, 989, "chunk990000"},
+		{"{name}{ext}.{index:d}", "-", 0, 0, 1, "stdin.1"},
+		{"{name}-{index}{ext}", "archive.tar.gz", 0, 0, 0, "archive.tar-0.gz"},
+		{"{{{index}}}", "", 0, 0, 7, "{7}"},
+	}
+
+	for _, tc := range testCases {
+		fileNameCreater, err := NewTemplateFileNameCreater(tc.template, tc.inputName)
+		if err != nil {
+			t.Errorf("Input: %s, Expected no error, Got: %v", tc.template, err)
+			continue
+		}
+		fileNameCreater.start = tc.start
+		if tc.total != 0 {
+			fileNameCreater.checkChunks(tc.total)
+		}
+		got, err := fileNameCreater.Create(tc.fileNumber)
+		if err != nil || got != tc.expectedFileName {
+			t.Errorf("Input: %s, %s, %d, Expected: %s, Got: %s, %v", tc.template, tc.inputName, tc.fileNumber, tc.expectedFileName, got, err)
+		}
+	}
+}
+
+func TestTemplateFileNameCreaterErrors(t *testing.T) {
+	for _, template := range []string{"", "{", "}", "{index", "{size}", "{name:04}", "{index:4q}", "{index:-1}"} {
+		if _, err := NewTemplateFileNameCreater(template, ""); err == nil || err.Error() != fmt.Sprintf(templateInvalidErrorMsg, template) {
+			t.Errorf("Input: %q, Expected: %s, Got: %v", template, fmt.Sprintf(templateInvalidErrorMsg, template), err)
+		}
+	}
+
+	fileNameCreater, _ := NewTemplateFileNameCreater("x{index}-of-{total}", "")
+	if _, err := fileNameCreater.Create(0); err == nil || err.Error() != templateTotalUnknownErrorMsg {
+		t.Errorf("Expected: %s, Got: %v", templateTotalUnknownErrorMsg, err)
+	}
+	fileNameCreater.checkChunks(3)
+	if _, err := fileNameCreater.Create(3); err == nil || err.Error() != tooBigFileNumberErrorMsg {
+		t.Errorf("Expected: %s, Got: %v", tooBigFileNumberErrorMsg, err)
+	}
+}
+
+func TestTemplateFileNameCreaterTemporaryNames(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, ".split0.tmp")
+	if err := os.WriteFile(other, []byte("another split"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	fileNameCreater, _ := NewTemplateFileNameCreater(filepath.Join(dir, "{sha256}"), "")
+
+	// Two splits to the same directory get different names for chunk 0.
+	first, err := fileNameCreater.Create(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := fileNameCreater.Create(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || filepath.Dir(first) != dir || first == other || second == other {
+		t.Errorf("Expected two new temporary names in %s, Got: %s, %s", dir, first, second)
+	}
+	if content, _ := os.ReadFile(other); string(content) != "another split" {
+		t.Errorf("A file already there was overwritten: %q", content)
+	}
+}
+
+func TestNewChunkSinkTemplate(t *testing.T) {
+	dir := t.TempDir()
+	data := "line 1\nline 2\nline 3\nline 4\nline 5\n"
+	options := Options{Chunks: "3", NameTemplate: filepath.Join(dir, "{name}.part{index:02}-of-{total:02}{ext}"), SuffixStart: 1, InputName: "orders.csv"}
+	splitter, err := NewSplitter(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewChunkSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := splitter.Split(strings.NewReader(data), sink); err != nil {
+		t.Fatal(err)
+	}
+
+	var output string
+	for _, name := range []string{"orders.part01-of-03.csv", "orders.part02-of-03.csv", "orders.part03-of-03.csv"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(name, " was not created.")
+		}
+		output += string(content)
+	}
+	if output != data {
+		t.Fatal("Incorrect output file content.")
+	}
+}
+
+func TestNewChunkSinkTemplateRenamesOnClose(t *testing.T) {
+	data := "line 1\nline 2\nline 3\nline 4\nline 5"
+	testCases := []struct {
+		options       Options
+		template      string
+		expectedNames []string
+	}{
+		{Options{Lines: 2}, "lines{first_line:03}-{last_line:03}", []string{"lines001-002", "lines003-004", "lines005-005"}},
+		{Options{Bytes: "10"}, "bytes{offset:04}", []string{"bytes0000", "bytes0010", "bytes0020", "bytes0030"}},
+		{Options{Bytes: "10"}, "{index}-lines{first_line}-{last_line}", []string{"0-lines1-2", "1-lines2-3", "2-lines3-5", "3-lines5-5"}},
+		{Options{Chunks: "l/2", Separator: "\\n"}, "{index}of{total}-{first_line}", []string{"0of2-1", "1of2-4"}},
+		{Options{Lines: 3, Compress: "gzip"}, "{offset}", []string{"0.gz", "21.gz"}},
+	}
+
+	for _, tc := range testCases {
+		dir := t.TempDir()
+		options := tc.options
+		options.NameTemplate = filepath.Join(dir, tc.template)
+		splitter, err := NewSplitter(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sink, err := NewChunkSink(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := splitter.Split(strings.NewReader(data), sink); err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if strings.Join(names, " ") != strings.Join(tc.expectedNames, " ") {
+			t.Errorf("Input: %+v, %s, Expected: %v, Got: %v", tc.options, tc.template, tc.expectedNames, names)
+		}
+	}
+}
+
+func TestNewChunkSinkTemplateDeferredOptions(t *testing.T) {
+	testCases := []struct {
+		options Options
+		err     string
+	}{
+		{Options{Chunks: "r/3"}, fmt.Sprintf(templateOrderOptionErrorMsg, "round robin Chunks")},
+		{Options{Bytes: "10", Parallel: 2}, fmt.Sprintf(templateOrderOptionErrorMsg, "Parallel")},
+		{Options{Filter: "cat"}, fmt.Sprintf(templateDeferredOptionErrorMsg, "Filter")},
+		{Options{Bytes: "10", Compress: "gzip", LimitAfterCompression: true}, fmt.Sprintf(templateDeferredOptionErrorMsg, "LimitAfterCompression")},
+	}
+
+	for _, tc := range testCases {
+		tc.options.NameTemplate = "x{offset}"
+		if _, err := NewChunkSink(tc.options); err == nil || err.Error() != tc.err {
+			t.Errorf("Input: %+v, Expected: %s, Got: %v", tc.options, tc.err, err)
+		}
+	}
+}
+
+func TestManifestTemplateNames(t *testing.T) {
+	dir := t.TempDir()
+	data := "line 1\nline 2\nline 3\n"
+	manifest := splitWithManifest(t, Options{Lines: 2, NameTemplate: filepath.Join(dir, "part-{first_line}"), InputName: "lines.txt"}, strings.NewReader(data))
+
+	if manifest.Suffix.Template != filepath.Join(dir, "part-{first_line}") {
+		t.Errorf("Incorrect suffix in the manifest: %+v", manifest.Suffix)
+	}
+	if len(manifest.Chunks) != 2 || manifest.Chunks[0].Name != filepath.Join(dir, "part-1") || manifest.Chunks[1].Name != filepath.Join(dir, "part-3") {
+		t.Fatalf("Incorrect chunks in the manifest: %+v", manifest.Chunks)
+	}
+	problems, err := VerifyManifest(manifest, manifest.Source())
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Expected no problems, Got: %v, %v", problems, err)
+	}
+}
+
+func TestNewChunkSinkContentNames(t *testing.T) {
+	data := "same\nsame\nabcd\n"
+	same, other := sha256Hex([]byte("same\n")), sha256Hex([]byte("abcd\n"))
+	testCases := []struct {
+		options       Options
+		expectedNames []string
+		files         int
+	}{
+		{Options{Lines: 1, ContentNames: true}, []string{"x" + same, "x" + same, "x" + other}, 2},
+		{Options{Lines: 1, ContentNamesIndex: true}, []string{"x00-" + same, "x01-" + same, "x02-" + other}, 3},
+		{Options{Lines: 1, ContentNamesIndex: true, Prefix: "part-", SuffixLength: 3}, []string{"part-000-" + same, "part-001-" + same, "part-002-" + other}, 3},
+		{Options{Bytes: "5", Parallel: 2, ContentNames: true}, []string{"x" + same, "x" + same, "x" + other}, 2},
+	}
+
+	for _, tc := range testCases {
+		dir := t.TempDir()
+		stdout := &bytes.Buffer{}
+		options := tc.options
+		options.OutputDir, options.Stdout = dir, stdout
+		splitter, err := NewSplitter(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sink, err := NewChunkSink(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := splitter.Split(strings.NewReader(data), sink); err != nil {
+			t.Fatal(err)
+		}
+
+		var expectedListing []string
+		for i, name := range tc.expectedNames {
+			content, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(name, " was not created.")
+			}
+			expectedListing = append(expectedListing, fmt.Sprintf("%d %s %s", i, sha256Hex(content), filepath.Join(dir, name)))
+		}
+		// The identical chunks share a file.
+		if entries, _ := os.ReadDir(dir); len(entries) != tc.files {
+			t.Errorf("Input: %+v, Incorrect number of output files: %d", tc.options, len(entries))
+		}
+
+		// The chunks copied at once may be listed in any order.
+		listing := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+		sort.Strings(listing)
+		if strings.Join(listing, "\n") != strings.Join(expectedListing, "\n") {
+			t.Errorf("Input: %+v, Expected: %v, Got: %v", tc.options, expectedListing, listing)
+		}
+	}
+}
+
+func TestNewChunkSinkContentNamesIndexOrder(t *testing.T) {
+	dir := t.TempDir()
+	var lines strings.Builder
+	for i := 0; i < 120; i++ {
+		fmt.Fprintln(&lines, "line", i)
+	}
+	options := Options{Lines: 1, ContentNamesIndex: true, Prefix: "p", OutputDir: dir, Stdout: io.Discard}
+	splitter, err := NewSplitter(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewChunkSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := splitter.Split(strings.NewReader(lines.String()), sink); err != nil {
+		t.Fatal(err)
+	}
+
+	// os.ReadDir sorts the names.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 120 {
+		t.Fatalf("Expected 120 output files, Got: %d", len(entries))
+	}
+	for i, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != fmt.Sprintf("line %d\n", i) {
+			t.Errorf("Chunk %d sorts as %s with %q", i, entry.Name(), content)
+		}
+	}
+}
+
+func TestManifestContentNames(t *testing.T) {
+	dir := t.TempDir()
+	stdout := &bytes.Buffer{}
+	data := "line 1\nline 2\nline 3\n"
+	manifest := splitWithManifest(t, Options{Lines: 2, ContentNames: true, Compress: "gzip", OutputDir: dir, Stdout: stdout}, strings.NewReader(data))
+
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no listing with a manifest, Got: %s", stdout.String())
+	}
+	if len(manifest.Chunks) != 2 {
+		t.Fatalf("Incorrect chunks in the manifest: %+v", manifest.Chunks)
+	}
+	for _, chunk := range manifest.Chunks {
+		content, err := os.ReadFile(chunk.Name)
+		if err != nil {
+			t.Fatal(chunk.Name, " was not created.")
+		}
+		if chunk.Name != filepath.Join(dir, "x"+sha256Hex(content)+".gz") || chunk.SHA256 != sha256Hex(content) || chunk.CompressedSize != int64(len(content)) {
+			t.Errorf("Incorrect chunk in the manifest: %+v", chunk)
+		}
+	}
+	problems, err := VerifyManifest(manifest, manifest.Source())
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Expected no problems, Got: %v, %v", problems, err)
+	}
+
+	if _, err := NewChunkSink(Options{ContentNames: true, NameTemplate: "{sha256}"}); err == nil || err.Error() != contentNamesTemplateErrorMsg {
+		t.Errorf("Expected: %s, Got: %v", contentNamesTemplateErrorMsg, err)
+	}
+}
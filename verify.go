@@ -0,0 +1,282 @@
+package split
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// VerifyProblemKind is what is wrong with a chunk.
+type VerifyProblemKind int
+
+const (
+	// ChunkMissing is a chunk that does not exist, or input that no chunk holds.
+	ChunkMissing VerifyProblemKind = iota + 1
+	// ChunkExtra is a chunk that should not exist, or data past the end of the input.
+	ChunkExtra
+	// ChunkResized is a chunk whose size differs from the manifest.
+	ChunkResized
+	// ChunkCorrupted is a chunk whose content differs from the manifest or the input.
+	ChunkCorrupted
+)
+
+func (kind VerifyProblemKind) String() string {
+	switch kind {
+	case ChunkMissing:
+		return "missing"
+	case ChunkExtra:
+		return "extra"
+	case ChunkResized:
+		return "resized"
+	case ChunkCorrupted:
+		return "corrupted"
+	}
+	return "unknown"
+}
+
+// VerifyProblem is a problem found in chunk Index.
+type VerifyProblem struct {
+	Index  int
+	Name   string
+	Kind   VerifyProblemKind
+	Detail string
+}
+
+func (problem VerifyProblem) String() string {
+	result := fmt.Sprintf("chunk %d", problem.Index)
+	if problem.Name != "" {
+		result += " (" + problem.Name + ")"
+	}
+	result += ": " + problem.Kind.String()
+	if problem.Detail != "" {
+		result += ", " + problem.Detail
+	}
+	return result
+}
+
+// Source returns a ChunkSource that reads the chunks by the names in the
+// manifest, and chunks past the last one by the names of its suffix scheme.
+// A name template may name the chunks by what they hold, so only the chunks
+// in the manifest are read then.
+func (manifest Manifest) Source() ChunkSource {
+	if manifest.Suffix.Template != "" {
+		return manifestSource{manifest, nil}
+	}
+	fileNameCreater := newFileNameCreater(manifest.Suffix)
+	if manifest.Suffix.Extension != "" {
+		fileNameCreater = NewExtensionFileNameCreater(fileNameCreater, manifest.Suffix.Extension)
+	}
+	return manifestSource{manifest, NewFileSource(fileNameCreater)}
+}
+
+type manifestSource struct {
+	manifest Manifest
+	// names reads the chunks past the last one, if any.
+	names ChunkSource
+}
+
+func (source manifestSource) Open(index int) (io.ReadCloser, error) {
+	for _, chunk := range source.manifest.Chunks {
+		if chunk.Index == index && chunk.Name != "" {
+			inFile, err := os.Open(chunk.Name)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf(chunkOpenErrorMsg, err)
+			}
+			return inFile, err
+		}
+	}
+	if source.names == nil {
+		return nil, fs.ErrNotExist
+	}
+	return source.names.Open(index)
+}
+
+// VerifyManifest recomputes the size and checksum of every chunk of the
+// manifest read from source. Chunks past the last one are reported as extra.
+func VerifyManifest(manifest Manifest, source ChunkSource) ([]VerifyProblem, error) {
+	var problems []VerifyProblem
+	nextIndex := 0
+	for _, chunk := range manifest.Chunks {
+		if chunk.Index >= nextIndex {
+			nextIndex = chunk.Index + 1
+		}
+		problem := VerifyProblem{Index: chunk.Index, Name: chunk.Name}
+
+		inFile, err := source.Open(chunk.Index)
+		if errors.Is(err, fs.ErrNotExist) {
+			problem.Kind = ChunkMissing
+			problems = append(problems, problem)
+			continue
+		}
+		if err != nil {
+			return problems, err
+		}
+		digest := sha256.New()
+		size, err := io.Copy(digest, inFile)
+		inFile.Close()
+		if err != nil {
+			return problems, fmt.Errorf(fileReadErrorMsg, err)
+		}
+
+		expectedSize := chunk.Length
+		if chunk.CompressedSize != 0 {
+			expectedSize = chunk.CompressedSize
+		}
+		if size != expectedSize {
+			problem.Kind = ChunkResized
+			problem.Detail = fmt.Sprintf("%d bytes instead of %d", size, expectedSize)
+			problems = append(problems, problem)
+		} else if hex.EncodeToString(digest.Sum(nil)) != chunk.SHA256 {
+			problem.Kind = ChunkCorrupted
+			problem.Detail = "checksum mismatch"
+			problems = append(problems, problem)
+		}
+	}
+
+	for index := nextIndex; ; index++ {
+		inFile, err := source.Open(index)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return problems, err
+		}
+		name := ""
+		if named, ok := inFile.(interface{ Name() string }); ok {
+			name = named.Name()
+		}
+		inFile.Close()
+		problems = append(problems, VerifyProblem{Index: index, Name: name, Kind: ChunkExtra})
+	}
+	return problems, nil
+}
+
+// VerifyOriginal stream-compares the chunks joined by joiner with original and
+// reports where they first differ: a corrupted chunk, input that no chunk
+// holds as missing, or data past the end of original as extra. An r/N chunk
+// with more lines than the chunks before it is extra too, and a chunk missing
+// from the N chunks of joiner is reported before any byte is compared.
+func VerifyOriginal(original io.Reader, joiner Joiner) ([]VerifyProblem, error) {
+	tracker := &trackingSource{source: joiner.source, last: -1}
+	joiner.source = tracker
+	compare := &compareWriter{original: bufio.NewReader(original), lines: newRecordCounter(joiner.separator)}
+
+	joined, err := joiner.Join(compare)
+	var inconsistent roundRobinInconsistentError
+	var missing chunkMissingError
+	if err != nil && !errors.Is(err, errVerifyStop) && !errors.As(err, &inconsistent) && !errors.As(err, &missing) {
+		return nil, err
+	}
+
+	// The chunk of the first differing byte.
+	index := tracker.last
+	if joiner.roundRobin && tracker.opened > 0 {
+		index = int(compare.lines.count % int64(tracker.opened))
+	}
+
+	switch {
+	case compare.mismatch:
+		return []VerifyProblem{{Index: index, Kind: ChunkCorrupted, Detail: fmt.Sprintf("differs from the original at byte %d", compare.offset)}}, nil
+	case compare.tooLong:
+		return []VerifyProblem{{Index: index, Kind: ChunkExtra, Detail: fmt.Sprintf("the original ends at byte %d", compare.offset)}}, nil
+	case errors.As(err, &missing):
+		return []VerifyProblem{{Index: missing.index, Kind: ChunkMissing, Detail: fmt.Sprintf("the set has %d chunks", missing.total)}}, nil
+	case err != nil:
+		return []VerifyProblem{{Index: inconsistent.index, Kind: ChunkExtra, Detail: "more lines than the chunks before it"}}, nil
+	}
+	if _, err := compare.original.Peek(1); err == nil {
+		return []VerifyProblem{{Index: joined, Kind: ChunkMissing, Detail: fmt.Sprintf("the chunks end at byte %d of the original", compare.offset)}}, nil
+	} else if err != io.EOF {
+		return nil, fmt.Errorf(fileReadErrorMsg, err)
+	}
+	return nil, nil
+}
+
+// errVerifyStop stops joining once the chunks differ from the original.
+var errVerifyStop = errors.New("chunks differ from the original")
+
+// trackingSource remembers the last chunk opened and how many were opened.
+type trackingSource struct {
+	source ChunkSource
+	last   int
+	opened int
+}
+
+func (source *trackingSource) Open(index int) (io.ReadCloser, error) {
+	inFile, err := source.source.Open(index)
+	if err == nil {
+		source.last = index
+		source.opened++
+	}
+	return inFile, err
+}
+
+// compareWriter compares everything written to it with original.
+type compareWriter struct {
+	original *bufio.Reader
+	buffer   []byte
+	offset   int64
+	// lines counts the lines compared, to find the r/N chunk of a difference.
+	lines    *recordCounter
+	mismatch bool
+	tooLong  bool
+}
+
+func (w *compareWriter) Write(p []byte) (int, error) {
+	if cap(w.buffer) < len(p) {
+		w.buffer = make([]byte, len(p))
+	}
+	expected := w.buffer[:len(p)]
+	n, err := io.ReadFull(w.original, expected)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return 0, fmt.Errorf(fileReadErrorMsg, err)
+	}
+	for i := 0; i < n; i++ {
+		if p[i] != expected[i] {
+			w.mismatch = true
+			w.lines.Write(p[:i])
+			w.offset += int64(i)
+			return i, errVerifyStop
+		}
+	}
+	w.lines.Write(p[:n])
+	w.offset += int64(n)
+	if n < len(p) {
+		w.tooLong = true
+		return n, errVerifyStop
+	}
+	return n, nil
+}
+
+// NewDecompressSource returns a ChunkSource that decompresses the chunks of
+// source, such as the ones written with Options.Compress.
+func NewDecompressSource(source ChunkSource) ChunkSource {
+	return decompressSource{source}
+}
+
+type decompressSource struct {
+	source ChunkSource
+}
+
+func (source decompressSource) Open(index int) (io.ReadCloser, error) {
+	inFile, err := source.source.Open(index)
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := NewDecompressReader(inFile)
+	if err != nil {
+		inFile.Close()
+		return nil, err
+	}
+	return readCloser{decompressed, inFile}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,168 @@
+package split
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func hundredLinesSplitWithManifest(t *testing.T, options Options) Manifest {
+	t.Helper()
+	options.Prefix = "output"
+	return splitWithManifest(t, options, strings.NewReader(hundredLines()))
+}
+
+func TestVerifyManifestIntact(t *testing.T) {
+
+	defer deleteOutputFiles()
+	for _, options := range []Options{{Lines: 30}, {Chunks: "r/4"}, {Bytes: "100", Compress: "gzip"}} {
+		manifest := hundredLinesSplitWithManifest(t, options)
+		problems, err := VerifyManifest(manifest, manifest.Source())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(problems) != 0 {
+			t.Errorf("Options: %+v, Expected no problem, Got: %v", options, problems)
+		}
+		deleteOutputFiles()
+	}
+}
+
+func TestVerifyManifestProblems(t *testing.T) {
+
+	defer deleteOutputFiles()
+	manifest := hundredLinesSplitWithManifest(t, Options{Lines: 20})
+
+	// outputaa grows, outputab goes missing, outputac changes and outputaf appears.
+	outFile, err := os.OpenFile("outputaa", os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outFile.Write([]byte("line 101\n"))
+	outFile.Close()
+	if err := os.Remove("outputab"); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile("outputac")
+	content[0] = 'L'
+	os.WriteFile("outputac", content, 0666)
+	os.WriteFile("outputaf", []byte("extra\n"), 0666)
+
+	problems, err := VerifyManifest(manifest, manifest.Source())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []VerifyProblem{
+		{Index: 0, Name: "outputaa", Kind: ChunkResized, Detail: "160 bytes instead of 151"},
+		{Index: 1, Name: "outputab", Kind: ChunkMissing},
+		{Index: 2, Name: "outputac", Kind: ChunkCorrupted, Detail: "checksum mismatch"},
+		{Index: 5, Name: "outputaf", Kind: ChunkExtra},
+	}
+	if fmt.Sprint(problems) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v, Got: %v", expected, problems)
+	}
+	if problems[0].String() != "chunk 0 (outputaa): resized, 160 bytes instead of 151" {
+		t.Errorf("Incorrect problem string: %s", problems[0])
+	}
+}
+
+func TestVerifyOriginal(t *testing.T) {
+
+	defer deleteOutputFiles()
+	testCases := []struct {
+		name     string
+		chunkStr string
+		damage   func()
+		original string
+		expected []VerifyProblem
+	}{
+		{"intact", "", func() {}, hundredLines(), nil},
+		{"corrupted", "", func() {
+			content, _ := os.ReadFile("outputab")
+			content[3] = 'E'
+			os.WriteFile("outputab", content, 0666)
+		}, hundredLines(), []VerifyProblem{{Index: 1, Kind: ChunkCorrupted, Detail: "differs from the original at byte 234"}}},
+		{"missing", "", func() { os.Remove("outputad") }, hundredLines(), []VerifyProblem{{Index: 3, Kind: ChunkMissing, Detail: "the chunks end at byte 711 of the original"}}},
+		{"extra", "", func() {}, hundredLines()[:500], []VerifyProblem{{Index: 2, Kind: ChunkExtra, Detail: "the original ends at byte 500"}}},
+		{"round robin corrupted", "r/4", func() {
+			// outputac holds lines 3, 7, 11, ... so its second line is line 7.
+			content, _ := os.ReadFile("outputac")
+			content = bytes.Replace(content, []byte("line 7\n"), []byte("line 8\n"), 1)
+			os.WriteFile("outputac", content, 0666)
+		}, hundredLines(), []VerifyProblem{{Index: 2, Kind: ChunkCorrupted, Detail: "differs from the original at byte 47"}}},
+		{"round robin missing", "r/4", func() { os.Remove("outputac") }, hundredLines(), []VerifyProblem{{Index: 2, Kind: ChunkMissing, Detail: "the set has 4 chunks"}}},
+		{"missing of N", "4", func() { os.Remove("outputac") }, hundredLines(), []VerifyProblem{{Index: 2, Kind: ChunkMissing, Detail: "the set has 4 chunks"}}},
+		{"round robin extra line", "r/4", func() {
+			content, _ := os.ReadFile("outputab")
+			os.WriteFile("outputab", append(content, "line 101\n"...), 0666)
+		}, hundredLines(), []VerifyProblem{{Index: 1, Kind: ChunkExtra, Detail: "more lines than the chunks before it"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer deleteOutputFiles()
+			options := Options{Lines: 30, Prefix: "output"}
+			if tc.chunkStr != "" {
+				options = Options{Chunks: tc.chunkStr, Prefix: "output"}
+			}
+			splitter, _ := NewSplitter(options)
+			sink, _ := NewChunkSink(options)
+			if err := splitter.Split(strings.NewReader(hundredLines()), sink); err != nil {
+				t.Fatal(err)
+			}
+			tc.damage()
+
+			joiner, err := NewJoiner(NewFileSource(NewFileNameCreater(options)), tc.chunkStr, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems, err := VerifyOriginal(strings.NewReader(tc.original), joiner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(problems) != fmt.Sprint(tc.expected) {
+				t.Fatalf("Expected %v, Got: %v", tc.expected, problems)
+			}
+		})
+	}
+}
+
+func TestVerifyOriginalCompressedChunks(t *testing.T) {
+
+	defer deleteOutputFiles()
+	manifest := hundredLinesSplitWithManifest(t, Options{Chunks: "3", Compress: "zlib"})
+
+	joiner, err := NewJoiner(NewDecompressSource(manifest.Source()), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := VerifyOriginal(strings.NewReader(hundredLines()), joiner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Fatal("Expected no problem, Got: ", problems)
+	}
+}
+
+func TestVerifyManifestOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	manifest := splitWithManifest(t, Options{Lines: 40, Prefix: "part", AdditionalSuffix: ".txt", OutputDir: dir}, strings.NewReader(hundredLines()))
+	if manifest.Chunks[0].Name != filepath.Join(dir, "partaa.txt") {
+		t.Fatalf("Incorrect chunk name in the manifest: %s", manifest.Chunks[0].Name)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "partad.txt"), []byte("extra\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := VerifyManifest(manifest, manifest.Source())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Kind != ChunkExtra || problems[0].Index != 3 {
+		t.Errorf("Expected the extra chunk 3, Got: %v", problems)
+	}
+}
@@ -1,163 +1,505 @@
-package main
+package split
 
 import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
+// FileSplitter reads the input and writes every chunk to the sink.
 type FileSplitter interface {
-	Split(file *os.File, fileNameCreater FileNameCreater) error
+	Split(reader io.Reader, sink ChunkSink) error
 }
 
 type LineSplitter struct {
 	separateLineNumber int64
+	// separator ends every line. The zero value means a newline.
+	separator string
+	// budget sizes the buffers.
+	budget memoryBudget
 }
 
 const bufferSize = 1024 * 1024
 
-func (s LineSplitter) Split(file *os.File, fileNameCreater FileNameCreater) error {
+// outputBufferSize is the size of the write buffer of every chunk the line
+// splitters write, so that short lines do not cost a write each.
+const outputBufferSize = 64 * 1024
 
-	// Initialize a buffer to store file data temporarily.
-	buffer := make([]byte, 0, bufferSize)
+// roundRobinBufferBudget is the most memory the write buffers of the r/N
+// chunks take together, since all of them are open at once.
+const roundRobinBufferBudget = 16 * 1024 * 1024
+
+// minOutputBufferSize is the smallest write buffer worth having. Smaller
+// shares of roundRobinBufferBudget leave the chunks unbuffered.
+const minOutputBufferSize = 4 * 1024
+
+func (s LineSplitter) Split(reader io.Reader, sink ChunkSink) error {
+	readSize, writeSize, err := s.budget.lineBuffers(1)
+	if err != nil {
+		return err
+	}
+
+	// Lines are copied in fragments of the buffer, so their length is not limited.
+	lines := bufio.NewReaderSize(reader, readSize)
+	separator := lineSeparator(s.separator)
 
 	// Line counter to keep track of lines read from the input file.
 	var lineCounter int64 = 0
 
-	var outFile *os.File
-	var outputFilePath string
-	var err error
+	var outFile io.WriteCloser
 	// Output file counter to keep track of split files.
 	outputCounter := 0
 
 	// Read the input file line by line.
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+	for {
+		more, err := hasLine(lines)
+		if err != nil {
+			closeChunk(outFile)
+			return err
+		}
+		if !more {
+			break
+		}
 
 		// If we have read 1000 lines, write to the output file.
 		if lineCounter == 0 || lineCounter%s.separateLineNumber == 0 {
-			// Increment the output file counter.
-
-			// Create the output file.
-			outputFilePath, err = fileNameCreater.Create(outputCounter)
-			if err != nil {
+			// Close the previous output file.
+			if err := closeChunk(outFile); err != nil {
 				return err
 			}
-			outFile, err = os.OpenFile(outputFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+
+			// Open the next output chunk.
+			outFile, err = openBufferedChunk(sink, outputCounter, writeSize)
 			if err != nil {
-				return fmt.Errorf(createFileErrorMsg, err)
+				return err
 			}
-			defer outFile.Close()
 			outputCounter++
 		}
 
 		// Increase the line counter.
 		lineCounter++
 
-		// Add the line to the buffer.
-		buffer, err = writeFileBy1Line(outFile, line, buffer)
-		if err != nil {
+		// Copy the line to the output file.
+		if err := writeLine(outFile, lines, separator); err != nil {
+			closeChunk(outFile)
 			return err
 		}
 
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf(fileReadErrorMsg, err)
+	return closeChunk(outFile)
+}
+
+// hasLine reports whether another line can be read from reader.
+func hasLine(reader *bufio.Reader) (bool, error) {
+	_, err := reader.Peek(1)
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf(fileReadErrorMsg, err)
+	}
+	return true, nil
+}
+
+// writeLine copies the next line of reader, up to and including separator,
+// to outFile byte for byte. A CRLF is kept as it is and no separator is
+// added to a last line without one. The line goes in fragments of the
+// reader's buffer, so it never has to fit in memory.
+func writeLine(outFile io.Writer, reader *bufio.Reader, separator string) error {
+	// A separator of several bytes is only complete when its last byte has
+	// been read after the others.
+	var counter *recordCounter
+	if len(separator) > 1 {
+		counter = newRecordCounter(separator)
+	}
+	for {
+		fragment, err := reader.ReadSlice(separator[len(separator)-1])
+		if len(fragment) > 0 {
+			if _, err := outFile.Write(fragment); err != nil {
+				return fmt.Errorf(fileWriteErrorMsg, err)
+			}
+			if counter != nil {
+				counter.Write(fragment)
+			}
+		}
+		switch err {
+		case nil:
+			if counter == nil || counter.ended {
+				return nil
+			}
+		case io.EOF:
+			return nil
+		case bufio.ErrBufferFull:
+			continue
+		default:
+			return fmt.Errorf(fileReadErrorMsg, err)
+		}
+	}
+}
+
+// bufferedChunk is an output chunk whose writes are buffered until the
+// buffer is full or the chunk is closed.
+type bufferedChunk struct {
+	*bufio.Writer
+	outFile io.WriteCloser
+}
+
+// openBufferedChunk opens the chunk index of sink with a write buffer of size
+// bytes. A size below minOutputBufferSize leaves the chunk unbuffered.
+func openBufferedChunk(sink ChunkSink, index int, size int) (io.WriteCloser, error) {
+	outFile, err := sink.Open(index)
+	if err != nil {
+		return nil, err
+	}
+	if size < minOutputBufferSize {
+		return outFile, nil
 	}
+	return &bufferedChunk{bufio.NewWriterSize(outFile, size), outFile}, nil
+}
 
-	return nil
+// Close flushes the buffer and closes the chunk.
+func (chunk *bufferedChunk) Close() error {
+	if err := chunk.Flush(); err != nil {
+		chunk.outFile.Close()
+		return err
+	}
+	return chunk.outFile.Close()
 }
 
-func writeFileBy1Line(outFile *os.File, line string, buffer []byte) ([]byte, error) {
-	buffer = append(buffer, line...)
-	buffer = append(buffer, '\n') // Add a newline character after each line.
+// bufferedOutput returns writer with a write buffer of size bytes. A size
+// below minOutputBufferSize leaves it unbuffered.
+func bufferedOutput(writer io.Writer, size int) io.Writer {
+	if size < minOutputBufferSize {
+		return writer
+	}
+	return bufio.NewWriterSize(writer, size)
+}
 
-	// Write the buffer data to the output file.
-	_, err := outFile.Write(buffer)
+// flushOutput flushes a buffered output unless err is set, and returns the
+// first error.
+func flushOutput(output io.Writer, err error) error {
 	if err != nil {
-		return nil, fmt.Errorf(fileWriteErrorMsg, err)
+		return err
 	}
+	if buffered, ok := output.(*bufio.Writer); ok {
+		if err := buffered.Flush(); err != nil {
+			return fmt.Errorf(fileWriteErrorMsg, err)
+		}
+	}
+	return nil
+}
+
+// createEmptyChunks creates the chunks of sink from index from up to to, empty.
+func createEmptyChunks(sink ChunkSink, from int, to int) error {
+	for index := from; index < to; index++ {
+		outFile, err := sink.Open(index)
+		if err != nil {
+			return err
+		}
+		if err := closeChunk(outFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// Reset the buffer and line counter for the next output file.
-	buffer = buffer[:0]
-	return buffer, nil
+// closeChunk closes an output chunk. A nil chunk has not been opened yet.
+func closeChunk(outFile io.Closer) error {
+	if outFile == nil {
+		return nil
+	}
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf(fileCloseErrorMsg, err)
+	}
+	return nil
 }
 
 type ByteSplitter struct {
 	separateByteStr string
+	// afterCompression applies the size to the compressed chunks. The sink
+	// must be a CompressSink.
+	afterCompression bool
+	// parallel is the number of chunks copied at once when the input can be
+	// read at any offset. 0 and 1 copy them one after another.
+	parallel int
+	// budget sizes the buffers.
+	budget memoryBudget
 }
 
-func (s ByteSplitter) Split(file *os.File, fileNameCreater FileNameCreater) error {
+func (s ByteSplitter) Split(reader io.Reader, sink ChunkSink) error {
 
 	separateByte, err := separateByteStrToInt(s.separateByteStr)
 	if err != nil {
 		return err
 	}
 
-	// Output file counter to keep track of split files.
-	outputCounter := 0
-
-	info, err := file.Stat()
+	compressor, err := compressingSinkOf(sink, s.afterCompression)
 	if err != nil {
 		return err
 	}
-	fileSize := info.Size()
-	if fileSize == 0 {
-		return nil
+	if input, ok := seekableRange(reader); ok && compressor == nil {
+		// The chunks are known up front from the input size, so their names
+		// are checked before any is written.
+		if err := checkChunkCount(sink, (input.Size()+int64(separateByte)-1)/int64(separateByte)); err != nil {
+			return err
+		}
+		// A file is copied by range, possibly in the kernel.
+		if s.parallel > 1 || input.file != nil {
+			return copyChunks(input, sink, int64(separateByte), s.parallel, s.budget)
+		}
 	}
+	blockSize := int64(min(separateByte, 1024))
+	if compressor != nil {
+		blockSize = compressedBlockSize
+	}
+	if err := s.budget.reserve(blockSize); err != nil {
+		return err
+	}
+
+	// Output file counter to keep track of split files.
+	outputCounter := 0
 
 	// Read the input file and write to the output files.
 	for {
+		var fileEnd bool
+		if compressor != nil {
+			fileEnd, err = writeCompressedChunkBySize(reader, compressor, outputCounter, int64(separateByte))
+		} else {
+			fileEnd, err = writeChunkBy1KSize(reader, sink, outputCounter, separateByte)
+		}
+		if err != nil {
+			return err
+		}
 
-		if err != nil && err != io.EOF {
-			return fmt.Errorf(fileReadErrorMsg, err)
+		if fileEnd {
+			break
 		}
+		// Increment the output file counter.
+		outputCounter++
+	}
+
+	return nil
+}
+
+// LineByteSplitter puts as many whole lines as fit in separateByteStr bytes
+// into every chunk. Only a line longer than that on its own is broken.
+type LineByteSplitter struct {
+	separateByteStr string
+	// separator ends every line. The zero value means a newline.
+	separator string
+	// budget sizes the buffers.
+	budget memoryBudget
+}
 
-		// Create the output file.
-		outputFilePath, err := fileNameCreater.Create(outputCounter)
+func (s LineByteSplitter) Split(reader io.Reader, sink ChunkSink) error {
+
+	separateByte, err := separateByteStrToInt(s.separateByteStr)
+	if err != nil {
+		return err
+	}
+	readSize, writeSize, err := s.budget.lineBuffers(1)
+	if err != nil {
+		return err
+	}
+	// A line that starts after others in a chunk is held until it is known
+	// to fit there, in memory as far as the budget allows.
+	holdSize := int64(separateByte)
+	if s.budget.limit > 0 {
+		holdSize = min(holdSize, s.budget.limit-int64(readSize+writeSize))
+	}
+
+	lines := bufio.NewReaderSize(reader, readSize)
+	separator := lineSeparator(s.separator)
+	output := &lineByteWriter{sink: sink, size: int64(separateByte), writeSize: writeSize, holdSize: int(holdSize)}
+	defer output.removeSpill()
+
+	for {
+		more, err := hasLine(lines)
 		if err != nil {
+			output.close()
 			return err
 		}
-		outFile, err := os.Create(outputFilePath)
-		if err != nil {
-			return fmt.Errorf(createFileErrorMsg, err)
+		if !more {
+			break
 		}
-		defer outFile.Close()
 
-		fileEnd, err := writeFileBy1KSize(file, outFile, outputFilePath, separateByte)
-		if err != nil {
+		output.startLine()
+		if err := writeLine(output, lines, separator); err != nil {
+			output.close()
 			return err
 		}
+		if err := output.endLine(); err != nil {
+			output.close()
+			return err
+		}
+	}
+	return output.close()
+}
+
+// lineByteWriter writes the lines of LineByteSplitter to chunks of at most
+// size bytes. A line that starts in an empty chunk goes straight to it, and
+// one that starts after other lines is held until it ends or no longer fits,
+// so less than size bytes are held. They are held in pending up to holdSize
+// bytes, and in a temporary file past that.
+type lineByteWriter struct {
+	sink      ChunkSink
+	size      int64
+	writeSize int
+	holdSize  int
+	index     int
+	outFile   io.WriteCloser
+	// used is the number of bytes written to the current chunk.
+	used int64
+	// held tells whether the current line is held.
+	held    bool
+	pending []byte
+	// spill holds the line once it outgrows holdSize, spilled bytes of it.
+	spill   *os.File
+	spilled int64
+}
+
+func (w *lineByteWriter) startLine() {
+	w.held = w.used > 0 && w.used < w.size
+}
+
+func (w *lineByteWriter) Write(p []byte) (int, error) {
+	if w.held {
+		if w.used+w.spilled+int64(len(w.pending)+len(p)) <= w.size {
+			if err := w.hold(p); err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+		// The line does not fit, so it starts the next chunk.
+		if err := w.nextChunk(); err != nil {
+			return 0, err
+		}
+		if err := w.writeHeld(); err != nil {
+			return 0, err
+		}
+	}
+	if err := w.write(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// endLine writes the held line, which fits in the current chunk.
+func (w *lineByteWriter) endLine() error {
+	if !w.held {
+		return nil
+	}
+	return w.writeHeld()
+}
 
-		// If the output file is empty, delete it.
-		outFileInfo, err := outFile.Stat()
+// hold adds p to the held line.
+func (w *lineByteWriter) hold(p []byte) error {
+	if w.spilled == 0 && len(w.pending)+len(p) <= w.holdSize {
+		w.pending = append(w.pending, p...)
+		return nil
+	}
+	if w.spill == nil {
+		spill, err := os.CreateTemp("", "split-spool-")
 		if err != nil {
-			return err
+			return fmt.Errorf(spoolFileErrorMsg, err)
 		}
-		size := outFileInfo.Size()
-		if size == 0 {
-			defer func() {
-				outFile.Close()
-				os.Remove(outputFilePath)
-			}()
+		w.spill = spill
+	}
+	for _, held := range [][]byte{w.pending, p} {
+		if _, err := w.spill.Write(held); err != nil {
+			return fmt.Errorf(spoolFileErrorMsg, err)
 		}
+		w.spilled += int64(len(held))
+	}
+	w.pending = w.pending[:0]
+	return nil
+}
 
-		if fileEnd {
-			break
+// writeHeld writes the held line to the current chunk and holds no more.
+func (w *lineByteWriter) writeHeld() error {
+	w.held = false
+	if w.spilled > 0 {
+		if _, err := w.spill.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf(spoolFileErrorMsg, err)
+		}
+		// pending is empty once the line is spilled, and its room copies it back.
+		if size := max(w.holdSize, minBufferSize); cap(w.pending) < size {
+			w.pending = make([]byte, 0, size)
+		}
+		buffer := w.pending[:cap(w.pending)]
+		for w.spilled > 0 {
+			n, err := io.ReadFull(w.spill, buffer[:min(int64(len(buffer)), w.spilled)])
+			if err != nil {
+				return fmt.Errorf(spoolFileErrorMsg, err)
+			}
+			if err := w.write(buffer[:n]); err != nil {
+				return err
+			}
+			w.spilled -= int64(n)
+		}
+		if _, err := w.spill.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf(spoolFileErrorMsg, err)
 		}
-		// Increment the output file counter.
-		outputCounter++
 	}
+	err := w.write(w.pending)
+	w.pending = w.pending[:0]
+	return err
+}
 
+// write writes p to the chunks, breaking it every size bytes.
+func (w *lineByteWriter) write(p []byte) error {
+	for len(p) > 0 {
+		if w.used == w.size {
+			if err := w.nextChunk(); err != nil {
+				return err
+			}
+		}
+		if w.outFile == nil {
+			outFile, err := openBufferedChunk(w.sink, w.index, w.writeSize)
+			if err != nil {
+				return err
+			}
+			w.outFile = outFile
+		}
+		n := int(min(int64(len(p)), w.size-w.used))
+		if _, err := w.outFile.Write(p[:n]); err != nil {
+			return fmt.Errorf(fileWriteErrorMsg, err)
+		}
+		w.used += int64(n)
+		p = p[n:]
+	}
 	return nil
 }
 
+// nextChunk closes the current chunk.
+func (w *lineByteWriter) nextChunk() error {
+	err := w.close()
+	w.index++
+	w.used = 0
+	return err
+}
+
+// close closes the current chunk, if any.
+func (w *lineByteWriter) close() error {
+	outFile := w.outFile
+	w.outFile = nil
+	return closeChunk(outFile)
+}
+
+// removeSpill removes the temporary file of the held lines, if any.
+func (w *lineByteWriter) removeSpill() {
+	if w.spill != nil {
+		w.spill.Close()
+		os.Remove(w.spill.Name())
+	}
+}
+
 func separateByteStrToInt(separateByteStr string) (int, error) {
 	re := regexp.MustCompile(`^(\d+)([kmgtpesy]*)b?$`)
 
@@ -194,164 +536,446 @@ func separateByteStrToInt(separateByteStr string) (int, error) {
 
 }
 
-func writeFileBy1KSize(file, outFile *os.File, outputputFilePath string, size int) (bool, error) {
+// writeChunkBy1KSize copies up to size bytes from reader to the chunk index of
+// sink. The chunk is opened only once there is data for it, so no empty chunk
+// is created. It reports whether the end of the input has been reached.
+func writeChunkBy1KSize(reader io.Reader, sink ChunkSink, index int, size int) (bool, error) {
 
 	// Create the buffer for reading the input file.
-	buffer := make([]byte, 1024)
+	buffer := make([]byte, min(max(size, 0), 1024))
+
+	var outFile io.WriteCloser
 
 	// Read 1KB of data from the input file.
 	for size > 0 {
-		if size < 1024 {
-			buffer = make([]byte, size)
-		}
-		n, err := file.Read(buffer)
+		n, err := reader.Read(buffer[:min(size, len(buffer))])
 		if err != nil && err != io.EOF {
+			closeChunk(outFile)
 			return false, fmt.Errorf(fileReadErrorMsg, err)
 		}
 
 		if n == 0 {
 			// Reached the end of the file, exit the loop.
-			return true, nil
+			return true, closeChunk(outFile)
+		}
+
+		if outFile == nil {
+			outFile, err = sink.Open(index)
+			if err != nil {
+				return false, err
+			}
 		}
 
 		// Write the buffer data to the output file.
 		_, err = outFile.Write(buffer[:n])
 		if err != nil {
+			closeChunk(outFile)
 			return false, fmt.Errorf(fileWriteErrorMsg, err)
 		}
 		size -= n
-
-		// Reset the buffer for the next output file.
-		buffer = make([]byte, 1024)
 	}
 
-	return false, nil
+	return false, closeChunk(outFile)
 }
 
 type PieceSplitter struct {
-	chunkStr string
+	chunkStr         string
+	writer           io.Writer
+	afterCompression bool
+	// separator ends the lines of l/N and r/N. The zero value means a newline.
+	separator string
+	// lineBalanced makes the l/N chunks of about the same number of lines
+	// instead of the same size.
+	lineBalanced bool
+	// parallel is the number of N chunks copied at once.
+	parallel int
+	// budget sizes the buffers.
+	budget memoryBudget
 }
 
-func (s PieceSplitter) Split(file *os.File, fileNameCreater FileNameCreater) error {
+func (s PieceSplitter) Split(reader io.Reader, sink ChunkSink) error {
 	chunk, err := parseCHUNK(s.chunkStr)
 	if err != nil {
 		return err
 	}
-	var splitter FileSplitter
+	var splitter pieceSplitter
 	if chunk.R {
-		splitter = PieceLineRoundRobinSplitter{chunk.N}
+		splitter = PieceLineRoundRobinSplitter{chunk.N, s.separator, s.budget}
 	} else if chunk.L {
-		splitter = PieceLineSplitter{chunk.N}
+		splitter = PieceLineSplitter{chunk.N, s.separator, s.lineBalanced, s.budget}
 	} else {
-		splitter = PieceByteSplitter{chunk.N}
+		splitter = PieceByteSplitter{chunk.N, s.afterCompression, s.parallel, s.budget}
 	}
 
-	err = splitter.Split(file, fileNameCreater)
-	if err != nil {
-		return err
+	if s.afterCompression && (chunk.R || chunk.L || chunk.K != 0) {
+		return fmt.Errorf(compressLimitModeErrorMsg)
 	}
-	if chunk.K > 0 {
-		fileName, err := fileNameCreater.Create(int(chunk.K) - 1)
-		if err != nil {
-			return err
-		}
-		file, err = os.Open(fileName)
-		if err != nil {
+
+	if chunk.K == 0 {
+		// At most N chunks are made, so their names are checked before any is written.
+		if err := checkChunkCount(sink, chunk.N); err != nil {
 			return err
 		}
-		defer file.Close()
+		return splitter.Split(reader, sink)
+	}
+
+	// Only chunk K is written, to the writer, and no chunk is created.
+	writer := s.writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+	return splitter.extract(reader, writer, chunk.K)
+
+}
+
+// pieceSplitter is a splitter of -n that can also write only chunk K of N.
+type pieceSplitter interface {
+	FileSplitter
+	// extract writes chunk k, counted from 1, to writer byte for byte.
+	extract(reader io.Reader, writer io.Writer, k int64) error
+}
 
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			fmt.Println(scanner.Text())
+// copyRangeBuffer copies size bytes of section from offset to writer through buffer.
+func copyRangeBuffer(writer io.Writer, section *io.SectionReader, offset int64, size int64, buffer []byte) error {
+	for size > 0 {
+		n, err := section.ReadAt(buffer[:min(size, int64(len(buffer)))], offset)
+		if n > 0 {
+			if _, err := writer.Write(buffer[:n]); err != nil {
+				return fmt.Errorf(fileWriteErrorMsg, err)
+			}
+			offset += int64(n)
+			size -= int64(n)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf(fileReadErrorMsg, err)
 		}
 	}
 	return nil
-
 }
 
 type PieceByteSplitter struct {
 	separatePieceNumber int64
+	// afterCompression makes the pieces roughly equal in compressed size.
+	// The sink must be a CompressSink.
+	afterCompression bool
+	// parallel is the number of pieces copied at once. 0 and 1 copy them one
+	// after another.
+	parallel int
+	// budget sizes the buffers.
+	budget memoryBudget
 }
 
-func (s PieceByteSplitter) Split(file *os.File, fileNameCreater FileNameCreater) error {
+func (s PieceByteSplitter) Split(reader io.Reader, sink ChunkSink) error {
 
-	// Get the file information.
-	info, err := file.Stat()
+	// The piece size depends on the input size, so a stream is spooled first.
+	input, cleanup, err := rangeOf(reader)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return err
 	}
+	defer cleanup()
+
 	// Calculate the size of each piece.
-	splitSize := info.Size() / s.separatePieceNumber
+	splitSize := input.Size() / s.separatePieceNumber
 
-	if info.Size()%s.separatePieceNumber != 0 {
+	if input.Size()%s.separatePieceNumber != 0 {
 		splitSize++
 	}
-	if info.Size() == 0 {
+	if input.Size() == 0 {
 		return nil
 	}
 
-	// Output file counter to keep track of split files.
-	outputCounter := 0
+	compressor, err := compressingSinkOf(sink, s.afterCompression)
+	if err != nil {
+		return err
+	}
+	if compressor != nil {
+		if err := s.budget.reserve(compressedBlockSize); err != nil {
+			return err
+		}
+		return s.splitCompressed(input.SectionReader, compressor)
+	}
 
-	// Read the input file and write to the output files.
-	for {
-		if err != nil && err != io.EOF {
-			return fmt.Errorf(fileReadErrorMsg, err)
+	// The pieces are copied by range, possibly in the kernel.
+	if err := copyChunks(input, sink, splitSize, s.parallel, s.budget); err != nil {
+		return err
+	}
+	// An input of fewer bytes than pieces leaves the last pieces empty, and
+	// they are still created, so that join finds all of them.
+	return createEmptyChunks(sink, int((input.Size()+splitSize-1)/splitSize), int(s.separatePieceNumber))
+}
+
+// extract copies the byte range of piece k only.
+func (s PieceByteSplitter) extract(reader io.Reader, writer io.Writer, k int64) error {
+	section, cleanup, err := sectionOf(reader)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	splitSize := section.Size() / s.separatePieceNumber
+	if section.Size()%s.separatePieceNumber != 0 {
+		splitSize++
+	}
+	offset := (k - 1) * splitSize
+	return s.budget.copyRange(writer, section, offset, min(splitSize, section.Size()-offset))
+}
+
+// splitCompressed limits the first pieces to an equal share of the compressed
+// size of the whole input. Every chunk has its own header and dictionary, so
+// the last piece takes whatever is left.
+func (s PieceByteSplitter) splitCompressed(section *io.SectionReader, sink compressingSink) error {
+	compressedSize, err := sink.CompressedSize(io.NewSectionReader(section, 0, section.Size()))
+	if err != nil {
+		return err
+	}
+	limit := compressedSize / s.separatePieceNumber
+	if compressedSize%s.separatePieceNumber != 0 {
+		limit++
+	}
+
+	for outputCounter := 0; int64(outputCounter) < s.separatePieceNumber; outputCounter++ {
+		// The pieces after the end of the input are created empty.
+		if offset, _ := section.Seek(0, io.SeekCurrent); offset == section.Size() {
+			return createEmptyChunks(sink, outputCounter, int(s.separatePieceNumber))
+		}
+		if int64(outputCounter) == s.separatePieceNumber-1 {
+			limit = math.MaxInt64
+		}
+		if _, err := writeCompressedChunkBySize(section, sink, outputCounter, limit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressingSinkOf returns sink as a compressingSink when the size limit
+// applies after compression, and nil otherwise.
+func compressingSinkOf(sink ChunkSink, afterCompression bool) (compressingSink, error) {
+	if !afterCompression {
+		return nil, nil
+	}
+	compressor, ok := sink.(compressingSink)
+	if !ok {
+		return nil, fmt.Errorf(compressLimitSinkErrorMsg)
+	}
+	return compressor, nil
+}
+
+// PieceLineSplitter makes separatePieceNumber chunks of about the same size
+// without breaking lines, like GNU split: chunk K ends with the line holding
+// byte K*size/N of the input. The chunks a line longer than a chunk spans are
+// left empty.
+type PieceLineSplitter struct {
+	separatePieceNumber int64
+	// separator ends every line. The zero value means a newline.
+	separator string
+	// lineBalanced makes chunks of about the same number of lines instead.
+	lineBalanced bool
+	// budget sizes the buffers.
+	budget memoryBudget
+}
+
+func (s PieceLineSplitter) Split(reader io.Reader, sink ChunkSink) error {
+
+	// The size is needed up front, so a stream is spooled first.
+	section, cleanup, err := sectionOf(reader)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if s.lineBalanced {
+		return s.splitLineBalanced(section, sink)
+	}
+	readSize, writeSize, err := s.budget.lineBuffers(1)
+	if err != nil {
+		return err
+	}
+
+	separator := lineSeparator(s.separator)
+	chunkSize := section.Size() / s.separatePieceNumber
+	// pieceEnd returns the byte after the nominal end of chunk piece.
+	pieceEnd := func(piece int64) int64 {
+		if piece == s.separatePieceNumber {
+			return section.Size()
 		}
+		return piece * chunkSize
+	}
 
-		// Create the output file.
-		outputFilePath, err := fileNameCreater.Create(outputCounter)
+	// The chunk being written, counted from 1, is named by its index.
+	piece := int64(1)
+	var outFile io.WriteCloser
+	var written *countingWriter
+	// Offset of the current chunk in the input.
+	var chunkStart int64
+
+	// Read the input file line by line.
+	lines := bufio.NewReaderSize(section, readSize)
+	for {
+		more, err := hasLine(lines)
 		if err != nil {
+			closeChunk(outFile)
 			return err
 		}
-		outFile, err := os.Create(outputFilePath)
-		if err != nil {
-			return fmt.Errorf(createFileErrorMsg, err)
+		if !more {
+			break
 		}
-		defer outFile.Close()
 
-		fileEnd, err := writeFileBy1KSize(file, outFile, outputFilePath, int(splitSize))
-		if err != nil {
+		// Open the next output chunk.
+		if outFile == nil {
+			outFile, err = openBufferedChunk(sink, int(piece-1), writeSize)
+			if err != nil {
+				return err
+			}
+			written = &countingWriter{writer: outFile}
+		}
+
+		if err := writeLine(written, lines, separator); err != nil {
+			closeChunk(outFile)
+			return err
+		}
+
+		// The chunk ends with the line holding its last byte.
+		lineEnd := chunkStart + written.count
+		if lineEnd < pieceEnd(piece) {
+			continue
+		}
+		if err := closeChunk(outFile); err != nil {
+			return err
+		}
+		outFile = nil
+		chunkStart = lineEnd
+
+		// A long line may also hold the ends of the next chunks, which are
+		// left empty so that every chunk keeps its index.
+		from := piece
+		piece++
+		for piece <= s.separatePieceNumber && pieceEnd(piece) <= lineEnd {
+			piece++
+		}
+		if err := createEmptyChunks(sink, int(from), int(piece-1)); err != nil {
 			return err
 		}
+	}
+
+	return closeChunk(outFile)
+}
+
+// extract reads from byte (k-1)*size/N - 1 to the end of its line, where
+// chunk k starts, and copies the lines up to the one holding byte k*size/N - 1.
+// Chunk k is empty when a line longer than a chunk spans it.
+func (s PieceLineSplitter) extract(reader io.Reader, writer io.Writer, k int64) error {
+	section, cleanup, err := sectionOf(reader)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	separator := lineSeparator(s.separator)
+	if s.lineBalanced {
+		return s.extractLineBalanced(section, writer, k, separator)
+	}
 
-		// If the output file is empty, delete it.
-		outFileInfo, err := outFile.Stat()
+	readSize, _, err := s.budget.lineBuffers(1)
+	if err != nil {
+		return err
+	}
+	chunkSize := section.Size() / s.separatePieceNumber
+	var start int64
+	if k > 1 {
+		start, err = lineEndFrom(section, max((k-1)*chunkSize-1, 0), separator, readSize)
 		if err != nil {
 			return err
 		}
-		if outFileInfo.Size() == 0 {
-			defer func() {
-				outFile.Close()
-				os.Remove(outputFilePath)
-			}()
+	}
+	end := section.Size()
+	if k < s.separatePieceNumber {
+		// Chunk k after the first is empty when the lines before it reach its end.
+		chunkEnd := k * chunkSize
+		if k > 1 && start >= chunkEnd {
+			return nil
+		}
+		end, err = lineEndFrom(section, max(chunkEnd-1, start), separator, readSize)
+		if err != nil {
+			return err
 		}
+	}
+	return s.budget.copyRange(writer, section, start, end-start)
+}
 
-		if fileEnd {
+// lineEndFrom returns the offset after the line of section holding byte
+// offset, or the size of section at its end. A separator of several bytes
+// may have begun before offset. The lines are read with a buffer of readSize.
+func lineEndFrom(section *io.SectionReader, offset int64, separator string, readSize int) (int64, error) {
+	position := max(offset-int64(len(separator)-1), 0)
+	lines := bufio.NewReaderSize(io.NewSectionReader(section, position, section.Size()-position), readSize)
+	for position <= offset {
+		more, err := hasLine(lines)
+		if err != nil {
+			return 0, err
+		}
+		if !more {
 			break
 		}
-
-		// Increment the output file counter.
-		outputCounter++
+		length := &countingWriter{writer: io.Discard}
+		if err := writeLine(length, lines, separator); err != nil {
+			return 0, err
+		}
+		position += length.count
 	}
-
-	return nil
+	return position, nil
 }
 
-type PieceLineSplitter struct {
-	separatePieceNumber int64
+// extractLineBalanced copies the lines of chunk k of about the same number of
+// lines.
+func (s PieceLineSplitter) extractLineBalanced(section *io.SectionReader, writer io.Writer, k int64, separator string) error {
+	readSize, writeSize, err := s.budget.lineBuffers(1)
+	if err != nil {
+		return err
+	}
+	fileLineNum, err := countLines(io.NewSectionReader(section, 0, section.Size()), separator, readSize)
+	if err != nil {
+		return err
+	}
+	fileLinesPerPiece := fileLineNum / s.separatePieceNumber
+	if fileLineNum%s.separatePieceNumber != 0 {
+		fileLinesPerPiece++
+	}
+
+	lines := bufio.NewReaderSize(section, readSize)
+	output := bufferedOutput(writer, writeSize)
+	for lineCounter := int64(0); lineCounter < k*fileLinesPerPiece; lineCounter++ {
+		more, err := hasLine(lines)
+		if err != nil || !more {
+			return flushOutput(output, err)
+		}
+		var outFile io.Writer = io.Discard
+		if lineCounter >= (k-1)*fileLinesPerPiece {
+			outFile = output
+		}
+		if err := writeLine(outFile, lines, separator); err != nil {
+			return err
+		}
+	}
+	return flushOutput(output, nil)
 }
 
-func (s PieceLineSplitter) Split(file *os.File, fileNameCreater FileNameCreater) error {
+// splitLineBalanced makes chunks of about the same number of lines, which
+// needs a first pass to count them.
+func (s PieceLineSplitter) splitLineBalanced(section *io.SectionReader, sink ChunkSink) error {
 
-	// Initialize a buffer to store file data temporarily.
-	buffer := make([]byte, 0, bufferSize)
-	var outFile *os.File
+	var outFile io.WriteCloser
+	readSize, writeSize, err := s.budget.lineBuffers(1)
+	if err != nil {
+		return err
+	}
 
 	// count file line number
-	fileLineNum, err := countLinesByFile(file)
+	separator := lineSeparator(s.separator)
+	fileLineNum, err := countLines(io.NewSectionReader(section, 0, section.Size()), separator, readSize)
 	if err != nil {
 		return err
 	}
@@ -369,25 +993,30 @@ func (s PieceLineSplitter) Split(file *os.File, fileNameCreater FileNameCreater)
 	outputCounter := 0
 
 	// Read the input file line by line.
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+	lines := bufio.NewReaderSize(section, readSize)
+	for {
+		more, err := hasLine(lines)
+		if err != nil {
+			closeChunk(outFile)
+			return err
+		}
+		if !more {
+			break
+		}
 
 		// If we have read 1000 lines, write to the output file.
 		if lineCounter == 0 || lineCounter%fileLinesPerPiece == 0 {
-			// Create the output file.
-			outputFilePath, err := fileNameCreater.Create(outputCounter)
-			if err != nil {
+			// Close the previous output file.
+			if err := closeChunk(outFile); err != nil {
 				return err
 			}
-			outFile, err = os.Create(outputFilePath)
+
+			// Open the next output chunk.
+			outFile, err = openBufferedChunk(sink, outputCounter, writeSize)
 			if err != nil {
-				return fmt.Errorf(createFileErrorMsg, err)
+				return err
 			}
 
-			// Close the output file.
-			defer outFile.Close()
-
 			// Increment the output file counter.
 			outputCounter++
 
@@ -396,67 +1025,69 @@ func (s PieceLineSplitter) Split(file *os.File, fileNameCreater FileNameCreater)
 		// Increase the line counter.
 		lineCounter++
 
-		buffer, err = writeFileBy1Line(outFile, line, buffer)
-		if err != nil {
+		if err := writeLine(outFile, lines, separator); err != nil {
+			closeChunk(outFile)
 			return err
 		}
 
 	}
 
-	// Check for errors.
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf(fileReadErrorMsg, err)
-	}
-
-	return nil
+	return closeChunk(outFile)
 }
 
 type PieceLineRoundRobinSplitter struct {
 	separatePieceNumber int64
+	// separator ends every line. The zero value means a newline.
+	separator string
+	// budget sizes the buffers.
+	budget memoryBudget
 }
 
-func (s PieceLineRoundRobinSplitter) Split(file *os.File, fileNameCreater FileNameCreater) error {
-
-	// Initialize a buffer to store file data temporarily.
-	buffer := make([]byte, 0, bufferSize)
-
-	// count file line number
-	fileLineNum, err := countLinesByFile(file)
+func (s PieceLineRoundRobinSplitter) Split(reader io.Reader, sink ChunkSink) error {
+	// Every chunk stays open, so they share the buffer budget.
+	readSize, writeSize, err := s.budget.lineBuffers(s.separatePieceNumber)
 	if err != nil {
 		return err
 	}
 
-	fileLinesPerPiece := fileLineNum / s.separatePieceNumber
-
-	if fileLineNum%s.separatePieceNumber != 0 {
-		fileLinesPerPiece++
-	}
-
 	// Line counter to keep track of lines read from the input file.
 	lineCounter := 0
 
-	scanner := bufio.NewScanner(file)
-	outFiles := make([]*os.File, 0, s.separatePieceNumber)
+	lines := bufio.NewReaderSize(reader, readSize)
+	separator := lineSeparator(s.separator)
+	outFiles := make([]io.WriteCloser, 0, s.separatePieceNumber)
+	closeAll := func() error {
+		var firstErr error
+		for _, outFile := range outFiles {
+			if err := closeChunk(outFile); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
 
-	for scanner.Scan() {
+	for {
+		more, err := hasLine(lines)
+		if err != nil {
+			closeAll()
+			return err
+		}
+		if !more {
+			break
+		}
 		if len(outFiles) == (lineCounter % int(s.separatePieceNumber)) {
-			outputFilePath, err := fileNameCreater.Create(lineCounter % int(s.separatePieceNumber))
+			outFile, err := openBufferedChunk(sink, lineCounter%int(s.separatePieceNumber), writeSize)
 			if err != nil {
+				closeAll()
 				return err
 			}
-			outFile, err := os.OpenFile(outputFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-			if err != nil {
-				return fmt.Errorf(createFileErrorMsg, err)
-			}
 			outFiles = append(outFiles, outFile)
-			defer outFile.Close()
 		}
-		line := scanner.Text()
-		// Create the output file.
+		// Pick the output file of this line.
 		outFile := outFiles[lineCounter%int(s.separatePieceNumber)]
 
-		buffer, err = writeFileBy1Line(outFile, line, buffer)
-		if err != nil {
+		if err := writeLine(outFile, lines, separator); err != nil {
+			closeAll()
 			return err
 		}
 
@@ -465,25 +1096,98 @@ func (s PieceLineRoundRobinSplitter) Split(file *os.File, fileNameCreater FileNa
 
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf(fileReadErrorMsg, err)
+	if err := closeAll(); err != nil {
+		return err
 	}
-
-	return nil
+	// Fewer lines than chunks leave the last chunks empty, and they are
+	// still created, so that join finds all of them.
+	if lineCounter == 0 {
+		return nil
+	}
+	return createEmptyChunks(sink, len(outFiles), int(s.separatePieceNumber))
 }
 
-func countLinesByFile(file *os.File) (int64, error) {
-	fileForCountLine, err := os.Open(file.Name())
+// extract copies every N-th line from line k, counted from 1. The lines
+// are dealt out from the start of the input, so all of it is read.
+func (s PieceLineRoundRobinSplitter) extract(reader io.Reader, writer io.Writer, k int64) error {
+	readSize, writeSize, err := s.budget.lineBuffers(1)
 	if err != nil {
+		return err
+	}
+	lines := bufio.NewReaderSize(reader, readSize)
+	output := bufferedOutput(writer, writeSize)
+	separator := lineSeparator(s.separator)
+	for lineCounter := int64(0); ; lineCounter++ {
+		more, err := hasLine(lines)
+		if err != nil || !more {
+			return flushOutput(output, err)
+		}
+		var outFile io.Writer = io.Discard
+		if lineCounter%s.separatePieceNumber == k-1 {
+			outFile = output
+		}
+		if err := writeLine(outFile, lines, separator); err != nil {
+			return err
+		}
+	}
+}
+
+// countLines counts the lines of reader ending with separator, and a last
+// line without one, a buffer of readSize at a time regardless of how long the
+// lines are.
+func countLines(reader io.Reader, separator string, readSize int) (int64, error) {
+	counter := newRecordCounter(separator)
+	if _, err := io.CopyBuffer(counter, reader, make([]byte, readSize)); err != nil {
 		return 0, fmt.Errorf(fileReadErrorMsg, err)
 	}
-	defer fileForCountLine.Close()
-	var count int64
-	scanner := bufio.NewScanner(fileForCountLine)
-	for scanner.Scan() {
-		count++
+	return counter.lines(), nil
+}
+
+// sectionOf returns the rest of reader as a section with random access and a
+// known size. Readers that cannot seek, such as stdin, are copied into a
+// temporary file first; cleanup removes that file.
+func sectionOf(reader io.Reader) (*io.SectionReader, func(), error) {
+	if seeker, ok := reader.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		if section, err := seekerSection(seeker); err == nil {
+			return section, func() {}, nil
+		}
+	}
+
+	spool, err := os.CreateTemp("", "split-spool-")
+	if err != nil {
+		return nil, nil, fmt.Errorf(spoolFileErrorMsg, err)
+	}
+	cleanup := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	size, err := io.Copy(spool, reader)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf(spoolFileErrorMsg, err)
+	}
+	return io.NewSectionReader(spool, 0, size), cleanup, nil
+}
+
+func seekerSection(seeker interface {
+	io.ReaderAt
+	io.Seeker
+}) (*io.SectionReader, error) {
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
 	}
-	return count, nil
+	return io.NewSectionReader(seeker, offset, end-offset), nil
 }
 
 type chunk struct {
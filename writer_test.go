@@ -1,18 +1,22 @@
-package main
+package split
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 var buffer *bytes.Buffer
 
 func init() {
 	buffer = &bytes.Buffer{}
-	writer = buffer
 }
 
 func TestLineFileSplitterSplit1000Lines(t *testing.T) {
@@ -22,7 +26,7 @@ func TestLineFileSplitterSplit1000Lines(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a LineFileSplitter instance.
-	splitter := LineSplitter{1000}
+	splitter := LineSplitter{separateLineNumber: 1000}
 
 	// Create a test file with 2007 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -43,7 +47,7 @@ func TestLineFileSplitterSplit1000Lines(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -107,7 +111,7 @@ func TestLineFileSplitterSplit500Lines(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a LineFileSplitter instance.
-	splitter := LineSplitter{500}
+	splitter := LineSplitter{separateLineNumber: 500}
 
 	// Create a test file with 2000 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -128,7 +132,7 @@ func TestLineFileSplitterSplit500Lines(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -182,7 +186,7 @@ func TestLineFileSplitterSplitWithEmptyFile(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a LineFileSplitter instance.
-	splitter := LineSplitter{1000}
+	splitter := LineSplitter{separateLineNumber: 1000}
 
 	// Create a test file with 0 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -200,7 +204,7 @@ func TestLineFileSplitterSplitWithEmptyFile(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -276,7 +280,7 @@ func TestByteFileSplitterSplit3072Byte(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a ByteSplitter instance.
-	splitter := ByteSplitter{"1k"}
+	splitter := ByteSplitter{separateByteStr: "1k"}
 
 	// Create a test file
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -305,7 +309,7 @@ func TestByteFileSplitterSplit3072Byte(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -375,7 +379,7 @@ func TestByteFileSplitterSplit2500Byte(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a ByteSplitter instance.
-	splitter := ByteSplitter{"1k"}
+	splitter := ByteSplitter{separateByteStr: "1k"}
 
 	// Create a test file
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -404,7 +408,7 @@ func TestByteFileSplitterSplit2500Byte(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -474,7 +478,7 @@ func TestByteFileSplitterSplit1mByte(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a ByteSplitter instance.
-	splitter := ByteSplitter{"1m"}
+	splitter := ByteSplitter{separateByteStr: "1m"}
 
 	// Create a test file
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -503,7 +507,7 @@ func TestByteFileSplitterSplit1mByte(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -573,7 +577,7 @@ func TestByteFileSplitterCannotSplit0ByteFile(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a LineFileSplitter instance.
-	splitter := ByteSplitter{"1m"}
+	splitter := ByteSplitter{separateByteStr: "1m"}
 
 	// Create a test file
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -602,7 +606,7 @@ func TestByteFileSplitterCannotSplit0ByteFile(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -666,7 +670,7 @@ func TestBytePieceSplitter5000ByteFileto3file(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceByteSplitter instance.
-	splitter := PieceByteSplitter{3}
+	splitter := PieceByteSplitter{separatePieceNumber: 3}
 
 	// Create a test file
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -695,7 +699,7 @@ func TestBytePieceSplitter5000ByteFileto3file(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -765,7 +769,7 @@ func TestBytePieceSplitter3000ByteFileto3file(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceByteSplitter instance.
-	splitter := PieceByteSplitter{3}
+	splitter := PieceByteSplitter{separatePieceNumber: 3}
 
 	// Create a test file
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -794,7 +798,7 @@ func TestBytePieceSplitter3000ByteFileto3file(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -864,7 +868,7 @@ func TestBytePieceSplitter0ByteFileto0file(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceByteSplitter instance.
-	splitter := PieceByteSplitter{3}
+	splitter := PieceByteSplitter{separatePieceNumber: 3}
 
 	// Create a test file
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -893,7 +897,7 @@ func TestBytePieceSplitter0ByteFileto0file(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -911,7 +915,7 @@ func TestPieceLineFileSplitterSplit2007Lines(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceLineSplitter instance.
-	splitter := PieceLineSplitter{3}
+	splitter := PieceLineSplitter{separatePieceNumber: 3}
 
 	// Create a test file with 2007 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -932,7 +936,7 @@ func TestPieceLineFileSplitterSplit2007Lines(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -954,23 +958,23 @@ func TestPieceLineFileSplitterSplit2007Lines(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if countLinesByByte(output1) != 669 {
-		t.Fatal("outputaa file has incorrect number of lines.Expected 669, got ", countLinesByByte(output1))
+	if countLinesByByte(output1) != 715 {
+		t.Fatal("outputaa file has incorrect number of lines.Expected 715, got ", countLinesByByte(output1))
 	}
 
 	output2, err := os.ReadFile("outputab")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output2) != 669 {
-		t.Fatal("outputab file has incorrect number of lines. Expected 669, got ", countLinesByByte(output2))
+	if countLinesByByte(output2) != 660 {
+		t.Fatal("outputab file has incorrect number of lines. Expected 660, got ", countLinesByByte(output2))
 	}
 	output3, err := os.ReadFile("outputac")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output3) != 669 {
-		t.Fatal("outputac file has incorrect number of lines. Expected 669, got ", countLinesByByte(output3))
+	if countLinesByByte(output3) != 632 {
+		t.Fatal("outputac file has incorrect number of lines. Expected 632, got ", countLinesByByte(output3))
 	}
 
 	if countFiles() != 3 {
@@ -996,7 +1000,7 @@ func TestPieceLineFileSplitterSplit713Lines(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceLineSplitter instance.
-	splitter := PieceLineSplitter{4}
+	splitter := PieceLineSplitter{separatePieceNumber: 4}
 
 	// Create a test file with 713 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1017,7 +1021,7 @@ func TestPieceLineFileSplitterSplit713Lines(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1042,30 +1046,30 @@ func TestPieceLineFileSplitterSplit713Lines(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if countLinesByByte(output1) != 179 {
-		t.Fatal("outputaa file has incorrect number of lines.Expected 179, got ", countLinesByByte(output1))
+	if countLinesByByte(output1) != 188 {
+		t.Fatal("outputaa file has incorrect number of lines.Expected 188, got ", countLinesByByte(output1))
 	}
 
 	output2, err := os.ReadFile("outputab")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output2) != 179 {
-		t.Fatal("outputab file has incorrect number of lines. Expected 179, got ", countLinesByByte(output2))
+	if countLinesByByte(output2) != 175 {
+		t.Fatal("outputab file has incorrect number of lines. Expected 175, got ", countLinesByByte(output2))
 	}
 	output3, err := os.ReadFile("outputac")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output3) != 179 {
-		t.Fatal("outputac file has incorrect number of lines. Expected 179, got ", countLinesByByte(output3))
+	if countLinesByByte(output3) != 175 {
+		t.Fatal("outputac file has incorrect number of lines. Expected 175, got ", countLinesByByte(output3))
 	}
 	output4, err := os.ReadFile("outputad")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output4) != 176 {
-		t.Fatal("outputad file has incorrect number of lines. Expected 176, got ", countLinesByByte(output4))
+	if countLinesByByte(output4) != 175 {
+		t.Fatal("outputad file has incorrect number of lines. Expected 175, got ", countLinesByByte(output4))
 	}
 
 	if countFiles() != 4 {
@@ -1091,7 +1095,7 @@ func TestPieceLineFileSplitterSplitWithEmptyFile(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceLineSplitter instance.
-	splitter := PieceLineSplitter{1000}
+	splitter := PieceLineSplitter{separatePieceNumber: 1000}
 
 	// Create a test file with 0 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1109,7 +1113,7 @@ func TestPieceLineFileSplitterSplitWithEmptyFile(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1120,6 +1124,69 @@ func TestPieceLineFileSplitterSplitWithEmptyFile(t *testing.T) {
 	}
 }
 
+func TestPieceLineFileSplitterLineBalanced(t *testing.T) {
+	testCases := []struct {
+		lineNumber int
+		pieces     int64
+		expected   []int
+	}{
+		{2007, 3, []int{669, 669, 669}},
+		{713, 4, []int{179, 179, 179, 176}},
+		{2005, 3, []int{669, 669, 667}},
+	}
+
+	for _, tc := range testCases {
+		var lines strings.Builder
+		for i := 1; i <= tc.lineNumber; i++ {
+			fmt.Fprintln(&lines, "line", i)
+		}
+		memory := &MemorySink{}
+		splitter := PieceLineSplitter{separatePieceNumber: tc.pieces, lineBalanced: true}
+		if err := splitter.Split(strings.NewReader(lines.String()), memory); err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for _, chunk := range memory.Chunks {
+			got = append(got, countLinesByByte(chunk.Bytes()))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+			t.Errorf("Input: %d lines to %d, Expected: %v, Got: %v", tc.lineNumber, tc.pieces, tc.expected, got)
+		}
+	}
+}
+
+func TestPieceLineFileSplitterLongLines(t *testing.T) {
+	testCases := []struct {
+		input    string
+		pieces   int64
+		expected []string
+	}{
+		// 12 bytes to 3: the chunks end with the lines holding bytes 3, 7 and 11.
+		{"a\nb\nc\nd\ne\nf\n", 3, []string{"a\nb\n", "c\nd\n", "e\nf\n"}},
+		// The long line holds the ends of the first two chunks, so chunk 2 is empty.
+		{"0123456789\na\n", 3, []string{"0123456789\n", "", "a\n"}},
+		// 42 bytes to 4: the 25-byte line spans chunk 2, and the last line chunk 4.
+		{"a\n" + strings.Repeat("x", 24) + "\n" + strings.Repeat("b", 14) + "\n", 4, []string{"a\n" + strings.Repeat("x", 24) + "\n", "", strings.Repeat("b", 14) + "\n", ""}},
+		// Fewer bytes than chunks.
+		{"a\nb\nc\n", 5, []string{"a\n", "", "b\n", "", "c\n"}},
+		{"abc", 2, []string{"abc", ""}},
+	}
+
+	for _, tc := range testCases {
+		memory := &MemorySink{}
+		if err := (PieceLineSplitter{separatePieceNumber: tc.pieces}).Split(strings.NewReader(tc.input), memory); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, chunk := range memory.Chunks {
+			got = append(got, chunk.String())
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tc.expected) {
+			t.Errorf("Input: %q to %d, Expected: %q, Got: %q", tc.input, tc.pieces, tc.expected, got)
+		}
+	}
+}
+
 func TestPieceLineRoundRobinFileSplitterSplit2007Lines(t *testing.T) {
 
 	defer deleteOutputFiles()
@@ -1127,7 +1194,7 @@ func TestPieceLineRoundRobinFileSplitterSplit2007Lines(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceLineRoundRobinSplitter instance.
-	splitter := PieceLineRoundRobinSplitter{3}
+	splitter := PieceLineRoundRobinSplitter{separatePieceNumber: 3}
 
 	// Create a test file with 2007 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1148,7 +1215,7 @@ func TestPieceLineRoundRobinFileSplitterSplit2007Lines(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1212,7 +1279,7 @@ func TestPieceLineRoundRobinFileSplitterSplit713Lines(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceLineRoundRobinSplitter instance.
-	splitter := PieceLineRoundRobinSplitter{4}
+	splitter := PieceLineRoundRobinSplitter{separatePieceNumber: 4}
 
 	// Create a test file with 713 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1233,7 +1300,7 @@ func TestPieceLineRoundRobinFileSplitterSplit713Lines(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1307,7 +1374,7 @@ func TestPieceLineRoundRobinFileSplitterSplitWithEmptyFile(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceLineRoundRobinSplitter instance.
-	splitter := PieceLineRoundRobinSplitter{1000}
+	splitter := PieceLineRoundRobinSplitter{separatePieceNumber: 1000}
 
 	// Create a test file with 0 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1325,7 +1392,7 @@ func TestPieceLineRoundRobinFileSplitterSplitWithEmptyFile(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1481,7 +1548,7 @@ func TestPieceSplitterSelectPieceByteSplitter(t *testing.T) {
 
 	chunkStr := "3"
 	// Create a PieceSplitter instance.
-	splitter := PieceSplitter{chunkStr}
+	splitter := PieceSplitter{chunkStr: chunkStr}
 
 	// Create a test file
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1510,7 +1577,7 @@ func TestPieceSplitterSelectPieceByteSplitter(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1580,7 +1647,7 @@ func TestPieceSplitterSelectPieceByteSplitterAndStdout(t *testing.T) {
 
 	chunkStr := "1/3"
 	// Create a PieceSplitter instance.
-	splitter := PieceSplitter{chunkStr}
+	splitter := PieceSplitter{chunkStr: chunkStr, writer: buffer}
 
 	// Create a test file
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1609,70 +1676,19 @@ func TestPieceSplitterSelectPieceByteSplitterAndStdout(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	// Check that the output files were created.
-	out1Stat, err := os.Stat("outputaa")
-	if os.IsNotExist(err) {
-		t.Fatal("outputaa file was not created.")
-	}
-	// Check that the output files have the correct size
-	if out1Stat.Size() != 1667 {
-		t.Fatal("outputaa file has incorrect size. Expected 1667, got ", out1Stat.Size())
-	}
-	out2Stat, err := os.Stat("outputab")
-	if os.IsNotExist(err) {
-		t.Fatal("outputab was not created.")
-	}
-	if out2Stat.Size() != 1667 {
-		t.Fatal("outputab file has incorrect size. Expected 1667, got ", out2Stat.Size())
-	}
-	out3Stat, err := os.Stat("outputac")
-	if os.IsNotExist(err) {
-		t.Fatal("outputac was not created.")
-	}
-	if out3Stat.Size() != 1665 {
-		t.Fatal("outputac file has incorrect size. Expected 1665, got ", out3Stat.Size())
-	}
-
-	// Check that the output files have the correct lines
-	output1, err := os.ReadFile("outputaa")
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	output2, err := os.ReadFile("outputab")
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	output3, err := os.ReadFile("outputac")
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	// Check that the output count is correct
-	if countFiles() != 3 {
-		t.Fatal("Incorrect number of output files.")
-	}
-
-	testFileContent, err := os.ReadFile(testFile.Name())
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	// Check that the output files have the correct content
-	testFileString := string(testFileContent)
-	if string(output1)+string(output2)+string(output3) != testFileString {
-		t.Fatal("Incorrect output file content.")
+	// Check that only chunk K was written, to the writer.
+	if countFiles() != 0 {
+		t.Fatal("Incorrect number of output files. Expected 0, got ", countFiles())
 	}
 
-	// Check that the output files stdout correct content
-	if buffer.String() == string(output1) {
-		t.Fatal("Incorrect output file content.")
+	// Check that the stdout has the content of the first chunk
+	if buffer.String() != string(data[:1667]) {
+		t.Fatal("Incorrect stdout content. Expected 1667 bytes, got ", buffer.Len())
 	}
 }
 
@@ -1683,7 +1699,7 @@ func TestPieceSplitterSelectPieceLineSplitter(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceSplitter instance.
-	splitter := PieceSplitter{"l/3"}
+	splitter := PieceSplitter{chunkStr: "l/3"}
 
 	// Create a test file with 2007 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1704,7 +1720,7 @@ func TestPieceSplitterSelectPieceLineSplitter(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1726,23 +1742,23 @@ func TestPieceSplitterSelectPieceLineSplitter(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if countLinesByByte(output1) != 669 {
-		t.Fatal("outputaa file has incorrect number of lines.Expected 669, got ", countLinesByByte(output1))
+	if countLinesByByte(output1) != 715 {
+		t.Fatal("outputaa file has incorrect number of lines.Expected 715, got ", countLinesByByte(output1))
 	}
 
 	output2, err := os.ReadFile("outputab")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output2) != 669 {
-		t.Fatal("outputab file has incorrect number of lines. Expected 669, got ", countLinesByByte(output2))
+	if countLinesByByte(output2) != 660 {
+		t.Fatal("outputab file has incorrect number of lines. Expected 660, got ", countLinesByByte(output2))
 	}
 	output3, err := os.ReadFile("outputac")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output3) != 669 {
-		t.Fatal("outputac file has incorrect number of lines. Expected 669, got ", countLinesByByte(output3))
+	if countLinesByByte(output3) != 632 {
+		t.Fatal("outputac file has incorrect number of lines. Expected 632, got ", countLinesByByte(output3))
 	}
 
 	if countFiles() != 3 {
@@ -1764,11 +1780,12 @@ func TestPieceSplitterSelectPieceLineSplitter(t *testing.T) {
 func TestPieceSplitterSelectPieceLineSplitterStdout3(t *testing.T) {
 
 	defer deleteOutputFiles()
+	buffer.Reset()
 	// Create a mock fileNameCreater.
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceSplitter instance.
-	splitter := PieceSplitter{"l/3/3"}
+	splitter := PieceSplitter{chunkStr: "l/3/3", writer: buffer}
 
 	// Create a test file with 2005 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1789,66 +1806,28 @@ func TestPieceSplitterSelectPieceLineSplitterStdout3(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	// Check that the output files were created.
-	if _, err := os.Stat("outputaa"); os.IsNotExist(err) {
-		t.Fatal("outputaa file was not created.")
-	}
-	if _, err := os.Stat("outputab"); os.IsNotExist(err) {
-		t.Fatal("outputab was not created.")
-	}
-	if _, err := os.Stat("outputac"); os.IsNotExist(err) {
-		t.Fatal("outputab was not created.")
-	}
-
-	// Check that the output files have the correct lines
-	output1, err := os.ReadFile("outputaa")
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if countLinesByByte(output1) != 669 {
-		t.Fatal("outputaa file has incorrect number of lines.Expected 669, got ", countLinesByByte(output1))
-	}
-
-	output2, err := os.ReadFile("outputab")
-	if err != nil {
-		t.Fatal(err)
-	}
-	if countLinesByByte(output2) != 669 {
-		t.Fatal("outputab file has incorrect number of lines. Expected 669, got ", countLinesByByte(output2))
-	}
-	output3, err := os.ReadFile("outputac")
-	if err != nil {
-		t.Fatal(err)
-	}
-	if countLinesByByte(output3) != 667 {
-		t.Fatal("outputac file has incorrect number of lines. Expected 667, got ", countLinesByByte(output3))
+	// Check that only chunk K was written, to the writer.
+	if countFiles() != 0 {
+		t.Fatal("Incorrect number of output files. Expected 0, got ", countFiles())
 	}
 
-	if countFiles() != 3 {
-		t.Fatal("Incorrect number of output files.")
+	// Check that the stdout has the lines of the third chunk, 1375 to 2005.
+	if countLinesByByte(buffer.Bytes()) != 631 {
+		t.Fatal("Incorrect number of lines on stdout. Expected 631, got ", countLinesByByte(buffer.Bytes()))
 	}
-
 	testFileContent, err := os.ReadFile(testFile.Name())
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	testFileString := string(testFileContent)
-	if string(output1)+string(output2)+string(output3) != testFileString {
-		t.Fatal("Incorrect output file content.")
+	lines := strings.SplitAfter(string(testFileContent), "\n")
+	if buffer.String() != strings.Join(lines[1374:], "") {
+		t.Fatal("Incorrect stdout content.")
 	}
-
-	// Check that the output files stdout correct content
-	if buffer.String() == string(output3) {
-		t.Fatal("Incorrect output file content.")
-	}
-
 }
 
 func TestPieceSplitterSelectPieceRoundRobinLineSplitter(t *testing.T) {
@@ -1858,7 +1837,7 @@ func TestPieceSplitterSelectPieceRoundRobinLineSplitter(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceSplitter instance.
-	splitter := PieceSplitter{"r/3"}
+	splitter := PieceSplitter{chunkStr: "r/3"}
 
 	// Create a test file with 2007 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1879,7 +1858,7 @@ func TestPieceSplitterSelectPieceRoundRobinLineSplitter(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1944,7 +1923,7 @@ func TestPieceSplitterSelectPieceRoundRobinLineSplitterStdout2(t *testing.T) {
 	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
 
 	// Create a PieceSplitter instance.
-	splitter := PieceSplitter{"r/2/3"}
+	splitter := PieceSplitter{chunkStr: "r/2/3", writer: buffer}
 
 	// Create a test file with 2005 lines.
 	testFile, err := os.CreateTemp("", "testfile.txt")
@@ -1965,64 +1944,450 @@ func TestPieceSplitterSelectPieceRoundRobinLineSplitterStdout2(t *testing.T) {
 	}
 
 	// Call the Split function with the mock fileNameCreater.
-	err = splitter.Split(testFile, fileNameCreater)
+	err = splitter.Split(testFile, FileSink{fileNameCreater})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// Check that the output files were created.
-	if _, err := os.Stat("outputaa"); os.IsNotExist(err) {
-		t.Fatal("outputaa file was not created.")
+	// Check that only chunk K was written, to the writer.
+	if countFiles() != 0 {
+		t.Fatal("Incorrect number of output files. Expected 0, got ", countFiles())
 	}
-	if _, err := os.Stat("outputab"); os.IsNotExist(err) {
-		t.Fatal("outputab was not created.")
+
+	// Check that the stdout has every third line from line 2.
+	var expected strings.Builder
+	for i := 2; i <= 2005; i += 3 {
+		fmt.Fprintln(&expected, "line", i)
 	}
-	if _, err := os.Stat("outputac"); os.IsNotExist(err) {
-		t.Fatal("outputab was not created.")
+	if buffer.String() != expected.String() {
+		t.Fatal("Incorrect stdout content. Expected 668 lines, got ", countLinesByByte(buffer.Bytes()))
 	}
+}
 
-	// Check that the output files have the correct lines
-	output1, err := os.ReadFile("outputaa")
+func pipeInput(t *testing.T, data []byte) *os.File {
+	t.Helper()
+	reader, writer, err := os.Pipe()
 	if err != nil {
 		t.Fatal(err)
 	}
+	go func() {
+		writer.Write(data)
+		writer.Close()
+	}()
+	t.Cleanup(func() { reader.Close() })
+	return reader
+}
 
-	if countLinesByByte(output1) != 669 {
-		t.Fatal("outputaa file has incorrect number of lines.Expected 669, got ", countLinesByByte(output1))
+func TestSplittersReadFromPipe(t *testing.T) {
+	var lines []byte
+	for i := 1; i <= 2007; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i)...)
 	}
 
-	output2, err := os.ReadFile("outputab")
-	if err != nil {
+	testCases := []struct {
+		name          string
+		splitter      FileSplitter
+		expectedFiles int
+	}{
+		{"LineSplitter", LineSplitter{separateLineNumber: 1000}, 3},
+		{"ByteSplitter", ByteSplitter{separateByteStr: "10k"}, 2},
+		{"PieceByteSplitter", PieceSplitter{chunkStr: "3"}, 3},
+		{"PieceLineSplitter", PieceSplitter{chunkStr: "l/3"}, 3},
+		{"PieceLineRoundRobinSplitter", PieceSplitter{chunkStr: "r/3"}, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer deleteOutputFiles()
+			fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
+
+			err := tc.splitter.Split(pipeInput(t, lines), FileSink{fileNameCreater})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if countFiles() != tc.expectedFiles {
+				t.Fatal("Incorrect number of output files. Expected ", tc.expectedFiles, ", got ", countFiles())
+			}
+			var total int
+			for i := 0; i < tc.expectedFiles; i++ {
+				name, _ := fileNameCreater.Create(i)
+				output, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				total += len(output)
+			}
+			if total != len(lines) {
+				t.Fatal("Incorrect total output size. Expected ", len(lines), ", got ", total)
+			}
+		})
+	}
+}
+
+func TestSplittersReadFromEmptyPipe(t *testing.T) {
+	for _, splitter := range []FileSplitter{LineSplitter{separateLineNumber: 1000}, ByteSplitter{separateByteStr: "1k"}, PieceSplitter{chunkStr: "3"}, PieceSplitter{chunkStr: "l/3"}, PieceSplitter{chunkStr: "r/3"}} {
+		func() {
+			defer deleteOutputFiles()
+			fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
+
+			err := splitter.Split(pipeInput(t, nil), FileSink{fileNameCreater})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if countFiles() != 0 {
+				t.Fatalf("%T created files from an empty pipe.", splitter)
+			}
+		}()
+	}
+}
+
+func TestLineSplittersWithLongLines(t *testing.T) {
+	// Lines longer than the read buffer, one of them with its CRLF split
+	// across two buffers, and a last line without a newline.
+	long := strings.Repeat("a", 3*bufferSize)
+	crlf := strings.Repeat("b", bufferSize-1) + "\r\n"
+	input := "short\n" + long + "\n" + crlf + "c\r\n" + long
+	expectedLines := []string{"short\n", long + "\n", crlf, "c\r\n", long}
+
+	testCases := []struct {
+		name     string
+		splitter FileSplitter
+		expected []string
+	}{
+		{"LineSplitter", LineSplitter{separateLineNumber: 2}, []string{
+			expectedLines[0] + expectedLines[1], expectedLines[2] + expectedLines[3], expectedLines[4]}},
+		{"PieceLineSplitter", PieceSplitter{chunkStr: "l/2"}, []string{
+			expectedLines[0] + expectedLines[1] + expectedLines[2], expectedLines[3] + expectedLines[4]}},
+		{"PieceLineRoundRobinSplitter", PieceSplitter{chunkStr: "r/2"}, []string{
+			expectedLines[0] + expectedLines[2] + expectedLines[4], expectedLines[1] + expectedLines[3]}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sink := &MemorySink{}
+			if err := tc.splitter.Split(strings.NewReader(input), sink); err != nil {
+				t.Fatal(err)
+			}
+			if len(sink.Chunks) != len(tc.expected) {
+				t.Fatal("Incorrect number of chunks. Expected ", len(tc.expected), ", got ", len(sink.Chunks))
+			}
+			for i, expected := range tc.expected {
+				if sink.Chunks[i].String() != expected {
+					t.Errorf("Incorrect content of chunk %d. Expected %d bytes, got %d bytes", i, len(expected), sink.Chunks[i].Len())
+				}
+			}
+		})
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{"", 0},
+		{"\n", 1},
+		{"a\nb\n", 2},
+		{"a\nb", 2},
+		{strings.Repeat("a", 2*bufferSize) + "\n" + strings.Repeat("b", bufferSize), 2},
+	}
+
+	for _, tc := range testCases {
+		count, err := countLines(strings.NewReader(tc.input), "\n", bufferSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != tc.expected {
+			t.Errorf("Input: %d bytes, Expected: %d, Got: %d", len(tc.input), tc.expected, count)
+		}
+	}
+}
+
+func TestWriteLineAcrossBuffers(t *testing.T) {
+	// bufio.Reader has a minimum buffer size of 16 bytes.
+	testCases := []struct {
+		input     string
+		separator string
+		expected  string
+	}{
+		{"0123456789abcdef\n", "\n", "0123456789abcdef\n"},
+		{"0123456789abcde\r\nx\n", "\n", "0123456789abcde\r\n"},
+		{"0123456789abcde\rx\n", "\n", "0123456789abcde\rx\n"},
+		{"0123456789abcdef0123456789abcdef0123", "\n", "0123456789abcdef0123456789abcdef0123"},
+		{"0123456789abcde\r", "\n", "0123456789abcde\r"},
+		{"0123456789abcdef\x00x", "\x00", "0123456789abcdef\x00"},
+		{"a\n-\nb\n---\nc\n", "\n---\n", "a\n-\nb\n---\n"},
+		// The separator is split across two buffers.
+		{"0123456789abcd\n---\nc", "\n---\n", "0123456789abcd\n---\n"},
+		{"0123456789abcd\n--\n---", "\n---\n", "0123456789abcd\n--\n---"},
+	}
+
+	for _, tc := range testCases {
+		var output bytes.Buffer
+		if err := writeLine(&output, bufio.NewReaderSize(strings.NewReader(tc.input), 16), tc.separator); err != nil {
+			t.Fatal(err)
+		}
+		if output.String() != tc.expected {
+			t.Errorf("Input: %q, Expected: %q, Got: %q", tc.input, tc.expected, output.String())
+		}
+	}
+}
+
+func TestLineByteSplitter(t *testing.T) {
+	testCases := []struct {
+		separateByteStr string
+		separator       string
+		input           string
+		expected        []string
+	}{
+		{"10", "", "aaa\nbbb\nccc\n", []string{"aaa\nbbb\n", "ccc\n"}},
+		{"8", "", "aaa\nbbb\nccc\n", []string{"aaa\nbbb\n", "ccc\n"}},
+		{"4", "", "aaa\nbbb\nccc", []string{"aaa\n", "bbb\n", "ccc"}},
+		// A line longer than the size is broken, and its rest is followed by the next lines.
+		{"4", "", "ab\nlonglongline\nc\n", []string{"ab\n", "long", "long", "line", "\nc\n"}},
+		{"5", "", "0123456789\n", []string{"01234", "56789", "\n"}},
+		{"6", "\x00", "aa\x00bb\x00cc\x00", []string{"aa\x00bb\x00", "cc\x00"}},
+		// A line held after another starts the next chunk once it does not fit.
+		{"6", "", "ab\ncdefgh\n", []string{"ab\n", "cdefgh", "\n"}},
+		{"8", "--", "ab--cd--efgh--", []string{"ab--cd--", "efgh--"}},
+		{"10", "", "", nil},
+	}
+
+	for _, tc := range testCases {
+		memory := &MemorySink{}
+		splitter := LineByteSplitter{separateByteStr: tc.separateByteStr, separator: tc.separator}
+		if err := splitter.Split(strings.NewReader(tc.input), memory); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, chunk := range memory.Chunks {
+			got = append(got, chunk.String())
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tc.expected) {
+			t.Errorf("Input: %q, Size: %s, Expected: %q, Got: %q", tc.input, tc.separateByteStr, tc.expected, got)
+		}
+	}
+}
+
+func TestLineByteSplitterStreams(t *testing.T) {
+	// The chunks are written as the input is read, before it fails.
+	memory := &MemorySink{}
+	reader := io.MultiReader(strings.NewReader("aaa\nbbb\nccc\n"), iotest.ErrReader(errors.New("broken pipe")))
+	if err := (LineByteSplitter{separateByteStr: "8"}).Split(reader, memory); err == nil {
+		t.Fatal("Expected the read error.")
+	}
+	if len(memory.Chunks) < 1 || memory.Chunks[0].String() != "aaa\nbbb\n" {
+		t.Fatalf("Expected the first chunk before the error, Got: %d chunks", len(memory.Chunks))
+	}
+}
+
+func TestLineByteSplitterFromPipe(t *testing.T) {
+	defer deleteOutputFiles()
+	var lines []byte
+	for i := 1; i <= 2007; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i)...)
+	}
+
+	fileNameCreater := AlphabetFileNameCreater{digit: 2, prefix: "output"}
+	if err := (LineByteSplitter{separateByteStr: "1k"}).Split(pipeInput(t, lines), FileSink{fileNameCreater}); err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output2) != 668 {
-		t.Fatal("outputab file has incorrect number of lines. Expected 668, got ", countLinesByByte(output2))
+
+	var joined []byte
+	for i := 0; i < countFiles(); i++ {
+		name, _ := fileNameCreater.Create(i)
+		output, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(output) > 1024 || output[len(output)-1] != '\n' {
+			t.Fatal("Chunk ", name, " is larger than 1K or breaks a line: ", len(output), " bytes")
+		}
+		joined = append(joined, output...)
+	}
+	if string(joined) != string(lines) {
+		t.Fatal("Joined content is not the original.")
 	}
-	output3, err := os.ReadFile("outputac")
-	if err != nil {
+}
+
+// countingReaderAt counts the bytes read through ReadAt.
+type countingReaderAt struct {
+	*strings.Reader
+	read int64
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	n, err := r.Reader.ReadAt(p, offset)
+	r.read += int64(n)
+	return n, err
+}
+
+func TestPieceSplitterExtractMatchesSplit(t *testing.T) {
+	var lines strings.Builder
+	for i := 1; i <= 2007; i++ {
+		fmt.Fprintf(&lines, "line %d%s\r\n", i, strings.Repeat("x", i%37))
+	}
+	lines.WriteString("no newline")
+	binary := string(randomBytes(5000))
+	// Some lines are longer than a chunk, so the chunks they span are empty.
+	var longLines strings.Builder
+	for i := 1; i <= 60; i++ {
+		length := i * i * 37 % 400
+		if i%13 == 0 {
+			length += 3000
+		}
+		fmt.Fprintf(&longLines, "%s\n", strings.Repeat("x", length))
+	}
+	spanned := "a\n" + strings.Repeat("x", 24) + "\n" + strings.Repeat("b", 14) + "\n"
+
+	testCases := []struct {
+		input     string
+		chunkStr  string
+		separator string
+		balanced  bool
+	}{
+		{binary, "7", "", false},
+		{lines.String(), "5", "", false},
+		{lines.String(), "l/5", "", false},
+		{lines.String(), "l/7", "\\r\\n", false},
+		{lines.String(), "l/5", "", true},
+		{lines.String(), "r/5", "", false},
+		{lines.String(), "r/4", "x\\r", false},
+		{longLines.String(), "l/9", "", false},
+		{longLines.String(), "l/20", "x\\n", false},
+		{spanned, "l/4", "", false},
+		{"a\nb\nc\n", "l/5", "", false},
+	}
+
+	for _, tc := range testCases {
+		memory := &MemorySink{}
+		splitter := PieceSplitter{chunkStr: tc.chunkStr, separator: mustParseSeparator(t, tc.separator), lineBalanced: tc.balanced}
+		if err := splitter.Split(strings.NewReader(tc.input), memory); err != nil {
+			t.Fatal(err)
+		}
+		chunk, _ := parseCHUNK(tc.chunkStr)
+		for k := int64(1); k <= chunk.N; k++ {
+			var output bytes.Buffer
+			splitter.chunkStr = strings.Replace(tc.chunkStr, "/", fmt.Sprintf("/%d/", k), 1)
+			if !chunk.L && !chunk.R {
+				splitter.chunkStr = fmt.Sprintf("%d/%s", k, tc.chunkStr)
+			}
+			splitter.writer = &output
+			if err := splitter.Split(strings.NewReader(tc.input), &MemorySink{}); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != memory.Chunks[k-1].String() {
+				t.Errorf("Chunk: %s, Expected %d bytes, Got %d bytes", splitter.chunkStr, memory.Chunks[k-1].Len(), output.Len())
+			}
+		}
+	}
+}
+
+func TestPieceSplitterExtractReadsOnlyChunk(t *testing.T) {
+	input := &countingReaderAt{Reader: strings.NewReader(string(randomBytes(10000)))}
+	var output bytes.Buffer
+	if err := (PieceSplitter{chunkStr: "3/4", writer: &output}).Split(input, &MemorySink{}); err != nil {
 		t.Fatal(err)
 	}
-	if countLinesByByte(output3) != 668 {
-		t.Fatal("outputac file has incorrect number of lines. Expected 668, got ", countLinesByByte(output3))
+	if output.Len() != 2500 || input.read != 2500 {
+		t.Fatal("Expected 2500 bytes read and written, got ", input.read, " and ", output.Len())
 	}
+}
 
-	if countFiles() != 3 {
-		t.Fatal("Incorrect number of output files.")
+func TestPieceSplitterExtractEmptyChunks(t *testing.T) {
+	testCases := []struct {
+		chunkStr string
+		expected string
+	}{
+		// A line longer than the chunks holds the ends of chunks 1 and 2.
+		{"l/1/3", "0123456789\n"},
+		{"l/2/3", ""},
+		{"l/3/3", "a\n"},
+		{"9/10", ""},
+		{"r/3/3", ""},
+	}
+
+	for _, tc := range testCases {
+		var output bytes.Buffer
+		if err := (PieceSplitter{chunkStr: tc.chunkStr, writer: &output}).Split(strings.NewReader("0123456789\na\n"), &MemorySink{}); err != nil {
+			t.Fatal(err)
+		}
+		if output.String() != tc.expected {
+			t.Errorf("Chunk: %s, Expected: %q, Got: %q", tc.chunkStr, tc.expected, output.String())
+		}
 	}
+}
 
-	testFileContent, err := os.ReadFile(testFile.Name())
+func mustParseSeparator(t *testing.T, separatorStr string) string {
+	t.Helper()
+	separator, err := parseSeparator(separatorStr)
 	if err != nil {
 		t.Fatal(err)
 	}
+	return separator
+}
 
-	testFileString := string(testFileContent)
-	if string(output1)+string(output2)+string(output3) == testFileString {
-		t.Fatal("output files have correct content. should be different.")
+// writeCountingSink counts the writes that reach the chunks of MemorySink.
+type writeCountingSink struct {
+	MemorySink
+	writes int
+}
+
+func (sink *writeCountingSink) Open(index int) (io.WriteCloser, error) {
+	outFile, err := sink.MemorySink.Open(index)
+	if err != nil {
+		return nil, err
 	}
+	return nopWriteCloser{writerFunc(func(p []byte) (int, error) {
+		sink.writes++
+		return outFile.Write(p)
+	})}, nil
+}
 
-	// Check that the output files stdout correct content
-	if buffer.String() == string(output2) {
-		t.Fatal("Incorrect output file content.")
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestLineSplittersBufferOutput(t *testing.T) {
+	var lines strings.Builder
+	for i := 1; i <= 100000; i++ {
+		fmt.Fprintln(&lines, "line", i)
 	}
+	data := lines.String()
 
+	testCases := []struct {
+		name      string
+		splitter  FileSplitter
+		maxWrites int
+	}{
+		// Every chunk is written when its buffer fills up and when it is closed.
+		{"LineSplitter", LineSplitter{separateLineNumber: 50000}, 2 * (len(data)/outputBufferSize + 2)},
+		{"PieceLineSplitter", PieceSplitter{chunkStr: "l/2"}, 2 * (len(data)/outputBufferSize + 2)},
+		{"PieceLineSplitterLineBalanced", PieceSplitter{chunkStr: "l/2", lineBalanced: true}, 2 * (len(data)/outputBufferSize + 2)},
+		{"PieceLineRoundRobinSplitter", PieceSplitter{chunkStr: "r/2"}, 2 * (len(data)/outputBufferSize + 2)},
+		// The budget is too small to buffer so many chunks, which get a write per line.
+		{"PieceLineRoundRobinSplitterUnbuffered", PieceSplitter{chunkStr: fmt.Sprint("r/", roundRobinBufferBudget/minOutputBufferSize+1)}, 100000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sink := &writeCountingSink{}
+			if err := tc.splitter.Split(strings.NewReader(data), sink); err != nil {
+				t.Fatal(err)
+			}
+			if sink.writes > tc.maxWrites {
+				t.Fatal("Too many writes. Expected at most ", tc.maxWrites, ", got ", sink.writes)
+			}
+			var output bytes.Buffer
+			for _, chunk := range sink.Chunks {
+				output.Write(chunk.Bytes())
+			}
+			if output.Len() != len(data) {
+				t.Fatal("Incorrect total chunk size. Expected ", len(data), ", got ", output.Len())
+			}
+		})
+	}
 }